@@ -0,0 +1,99 @@
+// Copyright (c) 2015 AKUALAB INC., All rights reserved.
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ju
+
+import (
+	"encoding/json"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// Summary holds the statistics computed by Aggregate.
+type Summary struct {
+	// Count is the number of objects with a numeric value.
+	Count int64
+	// Sum is the sum of all the values. Integers are summed exactly and their total is
+	// rounded once when it is added to the sum of the other values.
+	Sum float64
+	// IntSum is the exact sum of the integer values, nil if there are none.
+	IntSum *big.Int
+	// Min and Max are 0 if Count is 0.
+	Min, Max float64
+	// Missing is the number of objects without the field or where the field is null.
+	Missing int64
+	// Invalid is the number of values that are not json objects and of objects where the
+	// field is not a number, or is a number out of the range of float64.
+	Invalid int64
+}
+
+// Mean returns the average of the values, or NaN if there are none.
+func (s Summary) Mean() float64 {
+	if s.Count == 0 {
+		return math.NaN()
+	}
+	return s.Sum / float64(s.Count)
+}
+
+// Aggregate computes the count, sum, min, and max of the numeric values of the top level
+// field in the json objects in srcPath. Numbers are parsed from their json text; integers of any
+// size are summed exactly, see Summary. Objects without the field, objects where the field
+// is not a number, and values that are not objects are counted separately.
+// See FileStreamer for srcPath and ext.
+func Aggregate(srcPath string, field string, ext ...string) (Summary, error) {
+	var s Summary
+	var fsum float64
+	err := forEachRaw(srcPath, ext, func(raw json.RawMessage) error {
+		var m map[string]json.RawMessage
+		err := DefaultCodec.Unmarshal(raw, &m)
+		if err != nil || m == nil {
+			s.Invalid++
+			return nil
+		}
+		v, ok := m[field]
+		if !ok || string(v) == "null" {
+			s.Missing++
+			return nil
+		}
+		var n json.Number
+		if v[0] == '"' || DefaultCodec.Unmarshal(v, &n) != nil {
+			s.Invalid++
+			return nil
+		}
+		x, err := strconv.ParseFloat(n.String(), 64)
+		if err != nil {
+			s.Invalid++
+			return nil
+		}
+		if s.Count == 0 || x < s.Min {
+			s.Min = x
+		}
+		if s.Count == 0 || x > s.Max {
+			s.Max = x
+		}
+		if strings.ContainsAny(n.String(), ".eE") {
+			fsum += x
+		} else {
+			if s.IntSum == nil {
+				s.IntSum = new(big.Int)
+			}
+			i, _ := new(big.Int).SetString(n.String(), 10)
+			s.IntSum.Add(s.IntSum, i)
+		}
+		s.Count++
+		return nil
+	})
+	if err != nil {
+		return Summary{}, err
+	}
+	s.Sum = fsum
+	if s.IntSum != nil {
+		f, _ := new(big.Float).SetInt(s.IntSum).Float64()
+		s.Sum += f
+	}
+	return s, nil
+}
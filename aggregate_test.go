@@ -0,0 +1,90 @@
+// Copyright (c) 2015 AKUALAB INC., All rights reserved.
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ju
+
+import (
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestAggregate(t *testing.T) {
+
+	fn := filepath.Join(os.TempDir(), "aggregate", "a.json")
+	e := os.MkdirAll(filepath.Dir(fn), 0777)
+	if e != nil {
+		t.Fatal(e)
+	}
+	data := `{"v": 3}
+{"v": -1.5}
+{"v": 1e2}
+{"w": 4}
+{"v": null}
+{"v": "7"}
+{"v": [1]}
+{"v": 0}
+[1, 2]
+42
+null
+`
+	e = os.WriteFile(fn, []byte(data), 0644)
+	if e != nil {
+		t.Fatal(e)
+	}
+	s, err := Aggregate(fn, "v")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.IntSum == nil || s.IntSum.String() != "3" {
+		t.Fatalf("expected an integer sum of 3, got %v", s.IntSum)
+	}
+	s.IntSum = nil
+	expected := Summary{Count: 4, Sum: 101.5, Min: -1.5, Max: 100, Missing: 2, Invalid: 5}
+	if s != expected {
+		t.Fatalf("expected %+v, got %+v", expected, s)
+	}
+	if s.Mean() != 101.5/4 {
+		t.Fatalf("unexpected mean %v", s.Mean())
+	}
+
+	s, err = Aggregate(fn, "none")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.Count != 0 || s.Missing != 8 || s.Invalid != 3 || !math.IsNaN(s.Mean()) {
+		t.Fatalf("unexpected summary %+v", s)
+	}
+}
+
+func TestAggregateLargeIntegers(t *testing.T) {
+
+	fn := filepath.Join(os.TempDir(), "aggregate", "big.json")
+	e := os.MkdirAll(filepath.Dir(fn), 0777)
+	if e != nil {
+		t.Fatal(e)
+	}
+	data := `{"v": 9007199254740993}
+{"v": 1}
+{"v": 1e400}
+{"v": 123456789012345678901234567890}
+{"v": -123456789012345678901234567890}
+`
+	e = os.WriteFile(fn, []byte(data), 0644)
+	if e != nil {
+		t.Fatal(e)
+	}
+	s, err := Aggregate(fn, "v")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.IntSum == nil || s.IntSum.String() != "9007199254740994" {
+		t.Fatalf("expected an exact integer sum of 9007199254740994, got %v", s.IntSum)
+	}
+	if s.Sum != 9007199254740994 || s.Count != 4 || s.Invalid != 1 {
+		t.Fatalf("unexpected summary %+v", s)
+	}
+}
@@ -0,0 +1,159 @@
+// Copyright (c) 2015 AKUALAB INC., All rights reserved.
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ju
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"io"
+	"os"
+	"path"
+	"strings"
+)
+
+// zipSource reads the entries of a zip archive.
+type zipSource struct {
+	zr    *zip.ReadCloser
+	files []*zip.File
+	idx   int
+}
+
+func newZipSource(fn string, ext ...string) (*zipSource, error) {
+	zr, err := zip.OpenReader(fn)
+	if err != nil {
+		return nil, err
+	}
+	allowed := allowedExt(ext...)
+	zs := &zipSource{zr: zr}
+	for _, f := range zr.File {
+		if !matchEntry(f.Name, f.FileInfo().Mode().IsRegular(), allowed) {
+			continue
+		}
+		zs.files = append(zs.files, f)
+	}
+	return zs, nil
+}
+
+func (zs *zipSource) next() (string, io.ReadCloser, error) {
+	if zs.idx >= len(zs.files) {
+		return "", nil, io.EOF
+	}
+	f := zs.files[zs.idx]
+	zs.idx++
+	r, err := f.Open()
+	if err != nil {
+		return "", nil, fileError(f.Name, err)
+	}
+	if path.Ext(f.Name) == ".gz" {
+		gr, err := NewGZIPReader(r)
+		if err != nil {
+			r.Close()
+			return "", nil, fileError(f.Name, err)
+		}
+		return f.Name, gr, nil
+	}
+	return f.Name, r, nil
+}
+
+func (zs *zipSource) total() int {
+	return len(zs.files)
+}
+
+// Close releases the archive.
+func (zs *zipSource) Close() error {
+	if zs.zr == nil {
+		return nil
+	}
+	err := zs.zr.Close()
+	zs.zr = nil
+	zs.files = nil
+	return err
+}
+
+// matchEntry applies the directory rules to an archive entry. Archive entry names always use forward slashes.
+func matchEntry(name string, regular bool, allowed map[string]bool) bool {
+	if !regular || !fileNameRegexp.MatchString(path.Base(name)) {
+		return false
+	}
+	return matchExt(path.Ext(name), allowed)
+}
+
+// isTar returns true if the path extension is one of ".tar", ".tar.gz", or ".tgz".
+func isTar(fn string) bool {
+	return strings.HasSuffix(fn, ".tar") || strings.HasSuffix(fn, ".tar.gz") || strings.HasSuffix(fn, ".tgz")
+}
+
+// tarSource reads the entries of a tar archive, which may be gzipped.
+type tarSource struct {
+	f       *os.File
+	gz      *GZIPReader
+	tr      *tar.Reader
+	allowed map[string]bool
+	max     int // maximum number of entries to read, 0 means no limit
+	n       int // number of entries read
+}
+
+func newTarSource(fn string, ext ...string) (*tarSource, error) {
+	f, err := os.Open(fn)
+	if err != nil {
+		return nil, err
+	}
+	ts := &tarSource{f: f, allowed: allowedExt(ext...)}
+	if strings.HasSuffix(fn, ".gz") || strings.HasSuffix(fn, ".tgz") {
+		ts.gz, err = NewGZIPReader(f)
+		if err != nil {
+			f.Close()
+			return nil, err
+		}
+		ts.tr = tar.NewReader(ts.gz)
+	} else {
+		ts.tr = tar.NewReader(f)
+	}
+	return ts, nil
+}
+
+func (ts *tarSource) next() (string, io.ReadCloser, error) {
+	if ts.tr == nil || (ts.max > 0 && ts.n >= ts.max) {
+		return "", nil, io.EOF
+	}
+	for {
+		hdr, err := ts.tr.Next()
+		if err != nil {
+			return "", nil, err
+		}
+		if !matchEntry(hdr.Name, hdr.Typeflag == tar.TypeReg, ts.allowed) {
+			continue
+		}
+		ts.n++
+		r := io.NopCloser(ts.tr)
+		if path.Ext(hdr.Name) == ".gz" {
+			gr, err := NewGZIPReader(r)
+			if err != nil {
+				return "", nil, fileError(hdr.Name, err)
+			}
+			return hdr.Name, gr, nil
+		}
+		return hdr.Name, r, nil
+	}
+}
+
+// total is unknown until the whole archive is read.
+func (ts *tarSource) total() int {
+	return -1
+}
+
+// Close releases the archive.
+func (ts *tarSource) Close() error {
+	if ts.tr == nil {
+		return nil
+	}
+	ts.tr = nil
+	if ts.gz != nil {
+		// Closes the file too.
+		return ts.gz.Close()
+	}
+	return ts.f.Close()
+}
@@ -0,0 +1,256 @@
+// Copyright (c) 2015 AKUALAB INC., All rights reserved.
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ju
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestZipStreamer(t *testing.T) {
+
+	fn := filepath.Join(os.TempDir(), "archive", "data.zip")
+	e := os.MkdirAll(filepath.Dir(fn), 0777)
+	if e != nil {
+		t.Fatal(e)
+	}
+	f, err := os.Create(fn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	zw := zip.NewWriter(f)
+	ref := []tt{}
+	for k := 0; k < 4; k++ {
+		name := fmt.Sprintf("dir/testfile-%d.json", k)
+		if k%2 == 1 {
+			name += ".gz"
+		}
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var gw *gzip.Writer
+		if k%2 == 1 {
+			gw = gzip.NewWriter(w)
+		}
+		for i := 0; i < 5; i++ {
+			x := tt{Name: name, N: i}
+			ref = append(ref, x)
+			if gw != nil {
+				WriteJSON(gw, &x)
+			} else {
+				WriteJSON(w, &x)
+			}
+		}
+		if gw != nil {
+			gw.Close()
+		}
+	}
+	// Entries that must be skipped.
+	zw.Create("dir/")
+	w, _ := zw.Create("dir/notes.txt")
+	w.Write([]byte("not json"))
+	w, _ = zw.Create("dir/.hidden.json")
+	w.Write([]byte("not json"))
+	e = zw.Close()
+	if e != nil {
+		t.Fatal(e)
+	}
+	f.Close()
+
+	js, err := NewJSONStreamer(fn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	i := 0
+	for ; ; i++ {
+		var o tt
+		e := js.Next(&o)
+		if e == Done {
+			break
+		}
+		if e != nil {
+			t.Fatal(e)
+		}
+		if !ref[i].equal(o) {
+			t.Fatalf("mismatch, expected %v, got %v", ref[i], o)
+		}
+	}
+	if i != len(ref) {
+		t.Fatalf("expected %d objects, got %d", len(ref), i)
+	}
+	e = js.Close()
+	if e != nil {
+		t.Fatal(e)
+	}
+}
+
+func TestTarStreamer(t *testing.T) {
+
+	dir := filepath.Join(os.TempDir(), "archive")
+	e := os.MkdirAll(dir, 0777)
+	if e != nil {
+		t.Fatal(e)
+	}
+	for _, fn := range []string{filepath.Join(dir, "data.tar"), filepath.Join(dir, "data.tar.gz")} {
+		f, err := os.Create(fn)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var tw *tar.Writer
+		var gw *gzip.Writer
+		if filepath.Ext(fn) == ".gz" {
+			gw = gzip.NewWriter(f)
+			tw = tar.NewWriter(gw)
+		} else {
+			tw = tar.NewWriter(f)
+		}
+		tw.WriteHeader(&tar.Header{Name: "dir/", Typeflag: tar.TypeDir, Mode: 0755})
+		tw.WriteHeader(&tar.Header{Name: "dir/link.json", Typeflag: tar.TypeSymlink, Linkname: "testfile-0.json"})
+		ref := []tt{}
+		for k := 0; k < 3; k++ {
+			var buf bytes.Buffer
+			for i := 0; i < 5; i++ {
+				x := tt{Name: fmt.Sprintf("file %d", k), N: i}
+				ref = append(ref, x)
+				WriteJSON(&buf, &x)
+			}
+			hdr := &tar.Header{Name: fmt.Sprintf("dir/testfile-%d.json", k), Typeflag: tar.TypeReg, Mode: 0644, Size: int64(buf.Len())}
+			e := tw.WriteHeader(hdr)
+			if e != nil {
+				t.Fatal(e)
+			}
+			tw.Write(buf.Bytes())
+		}
+		tw.WriteHeader(&tar.Header{Name: "dir/notes.txt", Typeflag: tar.TypeReg, Mode: 0644, Size: 8})
+		tw.Write([]byte("not json"))
+		e := tw.Close()
+		if e != nil {
+			t.Fatal(e)
+		}
+		if gw != nil {
+			gw.Close()
+		}
+		f.Close()
+
+		js, err := NewJSONStreamer(fn)
+		if err != nil {
+			t.Fatal(err)
+		}
+		i := 0
+		for ; ; i++ {
+			var o tt
+			e := js.Next(&o)
+			if e == Done {
+				break
+			}
+			if e != nil {
+				t.Fatal(e)
+			}
+			if !ref[i].equal(o) {
+				t.Fatalf("mismatch, expected %v, got %v", ref[i], o)
+			}
+		}
+		if i != len(ref) {
+			t.Fatalf("%s: expected %d objects, got %d", fn, len(ref), i)
+		}
+		e = js.Close()
+		if e != nil {
+			t.Fatal(e)
+		}
+	}
+}
+
+func TestExpandPathsArchive(t *testing.T) {
+
+	fn := filepath.Join(os.TempDir(), "archive", "expand.zip")
+	e := os.MkdirAll(filepath.Dir(fn), 0777)
+	if e != nil {
+		t.Fatal(e)
+	}
+	f, err := os.Create(fn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	zw := zip.NewWriter(f)
+	for _, name := range []string{"b.json", "a.json", "notes.txt", ".hidden.json"} {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Write([]byte(`{"N":1}`))
+	}
+	zw.Close()
+	f.Close()
+
+	names, err := ExpandPaths(fn, ".json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fmt.Sprint(names) != "[b.json a.json]" {
+		t.Fatalf("unexpected entries: %v", names)
+	}
+}
+
+func TestMaxFilesArchive(t *testing.T) {
+
+	dir := filepath.Join(os.TempDir(), "archive")
+	e := os.MkdirAll(dir, 0777)
+	if e != nil {
+		t.Fatal(e)
+	}
+	var zb, tb bytes.Buffer
+	zw := zip.NewWriter(&zb)
+	tw := tar.NewWriter(&tb)
+	for i := 0; i < 4; i++ {
+		name := fmt.Sprintf("f%d.json", i)
+		data := []byte(fmt.Sprintf("{\"N\":%d}\n", i))
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Write(data)
+		tw.WriteHeader(&tar.Header{Name: name, Mode: 0644, Size: int64(len(data)), Typeflag: tar.TypeReg})
+		tw.Write(data)
+	}
+	zw.Close()
+	tw.Close()
+	for fn, b := range map[string][]byte{"max.zip": zb.Bytes(), "max.tar": tb.Bytes()} {
+		fn = filepath.Join(dir, fn)
+		e = os.WriteFile(fn, b, 0644)
+		if e != nil {
+			t.Fatal(e)
+		}
+		js, err := NewJSONStreamer(fn, WithMaxFiles(3))
+		if err != nil {
+			t.Fatal(err)
+		}
+		n := 0
+		for ; ; n++ {
+			var x tt
+			e := js.Next(&x)
+			if e == Done {
+				break
+			}
+			if e != nil {
+				t.Fatal(e)
+			}
+			if x.N != n {
+				t.Fatalf("%s: expected N=%d, got %d", fn, n, x.N)
+			}
+		}
+		js.Close()
+		if n != 3 {
+			t.Fatalf("%s: expected 3 objects, got %d", fn, n)
+		}
+	}
+}
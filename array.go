@@ -0,0 +1,109 @@
+// Copyright (c) 2015 AKUALAB INC., All rights reserved.
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ju
+
+import (
+	"bufio"
+	"io"
+)
+
+// WithAutoArray detects the format of each file. If the first non-whitespace character
+// is '[', the file is a json array and its elements are streamed as separate objects.
+// Otherwise, the file is read as a stream of json values, as usual.
+// Files of both formats can be mixed in the same directory.
+func WithAutoArray() Option {
+	return func(o *options) {
+		o.autoArray = true
+	}
+}
+
+const (
+	arrayDetect = iota
+	arrayElements
+	arrayPassthrough
+)
+
+// arrayReader turns a json array into a stream of values by removing the
+// enclosing brackets and the commas between the elements.
+type arrayReader struct {
+	rc    io.ReadCloser
+	br    *bufio.Reader
+	state int
+	depth int
+	inStr bool
+	esc   bool
+}
+
+func newArrayReader(rc io.ReadCloser) *arrayReader {
+	return &arrayReader{rc: rc, br: bufio.NewReader(rc)}
+}
+
+func (a *arrayReader) Read(p []byte) (int, error) {
+	if a.state == arrayDetect {
+		for {
+			c, err := a.br.ReadByte()
+			if err != nil {
+				return 0, err
+			}
+			if c == ' ' || c == '\t' || c == '\n' || c == '\r' {
+				continue
+			}
+			if c == '[' {
+				a.state = arrayElements
+			} else {
+				a.br.UnreadByte()
+				a.state = arrayPassthrough
+			}
+			break
+		}
+	}
+	n, err := a.br.Read(p)
+	if a.state == arrayElements {
+		a.scan(p[:n])
+	}
+	return n, err
+}
+
+// scan replaces the top level commas and the closing bracket with whitespace.
+func (a *arrayReader) scan(p []byte) {
+	for i, c := range p {
+		if a.inStr {
+			switch {
+			case a.esc:
+				a.esc = false
+			case c == '\\':
+				a.esc = true
+			case c == '"':
+				a.inStr = false
+			}
+			continue
+		}
+		switch c {
+		case '"':
+			a.inStr = true
+		case '{', '[':
+			a.depth++
+		case '}':
+			a.depth--
+		case ']':
+			if a.depth == 0 {
+				// End of the array, pass anything that follows to the decoder.
+				p[i] = '\n'
+				a.state = arrayPassthrough
+				return
+			}
+			a.depth--
+		case ',':
+			if a.depth == 0 {
+				p[i] = '\n'
+			}
+		}
+	}
+}
+
+func (a *arrayReader) Close() error {
+	return a.rc.Close()
+}
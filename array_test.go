@@ -0,0 +1,56 @@
+// Copyright (c) 2015 AKUALAB INC., All rights reserved.
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ju
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestAutoArray(t *testing.T) {
+
+	dir := filepath.Join(os.TempDir(), "autoarray")
+	os.RemoveAll(dir)
+	e := os.MkdirAll(dir, 0777)
+	if e != nil {
+		t.Fatal(e)
+	}
+	files := map[string]string{
+		"a.json": "\n [{\"Name\":\"a,]\\\"[\",\"N\":1,\"Words\":[\"x\",\"y\"]},\n {\"N\":2}]\n",
+		"b.json": "{\"N\":3}\n{\"N\":4,\"Words\":[\"z\"]}\n",
+		"c.json": "[]",
+	}
+	for name, data := range files {
+		e := os.WriteFile(filepath.Join(dir, name), []byte(data), 0644)
+		if e != nil {
+			t.Fatal(e)
+		}
+	}
+	expected := []tt{{Name: "a,]\"[", N: 1, Words: []string{"x", "y"}}, {N: 2}, {N: 3}, {N: 4, Words: []string{"z"}}}
+	js, err := NewJSONStreamer(dir, WithAutoArray())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer js.Close()
+	var i int
+	for ; ; i++ {
+		var o tt
+		e := js.Next(&o)
+		if e == Done {
+			break
+		}
+		if e != nil {
+			t.Fatal(e)
+		}
+		if !expected[i].equal(o) {
+			t.Fatalf("expected %v, got %v", expected[i], o)
+		}
+	}
+	if i != len(expected) {
+		t.Fatalf("expected %d objects, got %d", len(expected), i)
+	}
+}
@@ -0,0 +1,41 @@
+// Copyright (c) 2015 AKUALAB INC., All rights reserved.
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ju
+
+import (
+	"errors"
+	"fmt"
+)
+
+// WithAutoID makes a Writer set the top-level field of each object to a sequential id,
+// starting at start and incremented on each successful Write. If the object already has the
+// field, its value is replaced and the field keeps its position; otherwise, the field is added
+// at the end. The objects must encode to json objects. The value passed to Write is not modified.
+func WithAutoID(field string, start int64) Option {
+	return func(o *options) {
+		if field == "" {
+			o.err = errors.New("auto id field name is empty")
+			return
+		}
+		o.autoID = field
+		o.autoIDStart = start
+	}
+}
+
+// withID returns v encoded as an ordered map with the id field set.
+func (w *Writer) withID(v interface{}) (*OrderedMap, error) {
+	data, err := DefaultCodec.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	om := NewOrderedMap()
+	err = om.UnmarshalJSON(data)
+	if err != nil {
+		return nil, fmt.Errorf("cannot set id field %q: %w", w.idField, err)
+	}
+	om.Set(w.idField, w.nextID)
+	return om, nil
+}
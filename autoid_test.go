@@ -0,0 +1,66 @@
+// Copyright (c) 2015 AKUALAB INC., All rights reserved.
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ju
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestAutoID(t *testing.T) {
+
+	fn := filepath.Join(os.TempDir(), "autoid", "ids.json.gz")
+	w, err := NewWriter(fn, WithAutoID("id", 100))
+	if err != nil {
+		t.Fatal(err)
+	}
+	objs := []interface{}{
+		tt{Name: "a", N: 1},
+		map[string]interface{}{"id": "old", "x": 1.5},
+		[]int{1},
+		struct{}{},
+	}
+	for i, o := range objs {
+		e := w.Write(o)
+		if i == 2 {
+			if e == nil {
+				t.Fatal("expected error for array value")
+			}
+			continue
+		}
+		if e != nil {
+			t.Fatal(e)
+		}
+	}
+	e := w.Close()
+	if e != nil {
+		t.Fatal(e)
+	}
+
+	r, err := FileStreamer(fn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	b, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `{"Name":"a","N":1,"Words":null,"id":100}
+{"id":101,"x":1.5}
+{"id":102}
+`
+	if string(b) != expected {
+		t.Fatalf("expected:\n%s\ngot:\n%s", expected, string(b))
+	}
+
+	_, err = NewWriter(fn, WithAutoID("", 0))
+	if err == nil {
+		t.Fatal("expected error for empty field name")
+	}
+}
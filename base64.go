@@ -0,0 +1,109 @@
+// Copyright (c) 2015 AKUALAB INC., All rights reserved.
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ju
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"io"
+)
+
+// WithBase64 reads and writes json objects encoded in base64, one object per line.
+// Streamers decode each line before parsing the json and writers encode each object.
+// If enc is nil, base64.StdEncoding is used. Use base64.URLEncoding for the URL-safe alphabet.
+// Empty lines are ignored.
+func WithBase64(enc *base64.Encoding) Option {
+	return func(o *options) {
+		if enc == nil {
+			enc = base64.StdEncoding
+		}
+		o.base64 = enc
+	}
+}
+
+// base64LineReader decodes base64 lines.
+type base64LineReader struct {
+	rc  io.ReadCloser
+	br  *bufio.Reader
+	enc *base64.Encoding
+	buf []byte
+	err error
+}
+
+func newBase64LineReader(rc io.ReadCloser, enc *base64.Encoding) *base64LineReader {
+	return &base64LineReader{rc: rc, br: bufio.NewReader(rc), enc: enc}
+}
+
+func (b *base64LineReader) Read(p []byte) (int, error) {
+	for len(b.buf) == 0 {
+		if b.err != nil {
+			return 0, b.err
+		}
+		line, err := b.br.ReadBytes('\n')
+		if err != nil {
+			b.err = err
+		}
+		line = bytes.TrimSpace(line)
+		if len(line) == 0 {
+			continue
+		}
+		dec := make([]byte, b.enc.DecodedLen(len(line)), b.enc.DecodedLen(len(line))+1)
+		n, e := b.enc.Decode(dec, line)
+		if e != nil {
+			b.err = e
+			return 0, e
+		}
+		b.buf = append(dec[:n], '\n')
+	}
+	n := copy(p, b.buf)
+	b.buf = b.buf[n:]
+	return n, nil
+}
+
+func (b *base64LineReader) Close() error {
+	return b.rc.Close()
+}
+
+// base64LineWriter encodes each line written to it in base64.
+type base64LineWriter struct {
+	w   io.Writer
+	enc *base64.Encoding
+	buf []byte
+}
+
+func (b *base64LineWriter) Write(p []byte) (int, error) {
+	b.buf = append(b.buf, p...)
+	for {
+		i := bytes.IndexByte(b.buf, '\n')
+		if i < 0 {
+			return len(p), nil
+		}
+		err := b.writeLine(b.buf[:i])
+		if err != nil {
+			return 0, err
+		}
+		b.buf = b.buf[i+1:]
+	}
+}
+
+func (b *base64LineWriter) writeLine(line []byte) error {
+	out := make([]byte, b.enc.EncodedLen(len(line))+1)
+	b.enc.Encode(out, line)
+	out[len(out)-1] = '\n'
+	_, err := b.w.Write(out)
+	return err
+}
+
+// Close writes the last line if it does not end with a newline.
+func (b *base64LineWriter) Close() error {
+	if len(b.buf) == 0 {
+		return nil
+	}
+	err := b.writeLine(b.buf)
+	b.buf = nil
+	return err
+}
@@ -0,0 +1,63 @@
+// Copyright (c) 2015 AKUALAB INC., All rights reserved.
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ju
+
+import (
+	"bytes"
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestBase64(t *testing.T) {
+
+	dir := filepath.Join(os.TempDir(), "base64")
+	os.RemoveAll(dir)
+	ref := []tt{{Name: "a?>", N: 1}, {Name: "b", N: 2, Words: []string{"x"}}}
+	for _, enc := range []*base64.Encoding{nil, base64.URLEncoding} {
+		fn := filepath.Join(dir, "b64.json")
+		w, err := NewWriter(fn, WithBase64(enc))
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i := range ref {
+			w.Write(&ref[i])
+		}
+		e := w.Close()
+		if e != nil {
+			t.Fatal(e)
+		}
+		data, _ := os.ReadFile(fn)
+		lines := bytes.Split(bytes.TrimSpace(data), []byte("\n"))
+		if len(lines) != 2 || bytes.Contains(data, []byte("{")) {
+			t.Fatalf("unexpected encoded data: %s", data)
+		}
+
+		js, err := NewJSONStreamer(fn, WithBase64(enc))
+		if err != nil {
+			t.Fatal(err)
+		}
+		i := 0
+		for ; ; i++ {
+			var o tt
+			e := js.Next(&o)
+			if e == Done {
+				break
+			}
+			if e != nil {
+				t.Fatal(e)
+			}
+			if !ref[i].equal(o) {
+				t.Fatalf("mismatch, expected %v, got %v", ref[i], o)
+			}
+		}
+		js.Close()
+		if i != len(ref) {
+			t.Fatalf("expected %d objects, got %d", len(ref), i)
+		}
+	}
+}
@@ -0,0 +1,65 @@
+// Copyright (c) 2015 AKUALAB INC., All rights reserved.
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ju
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+)
+
+// WithBlankLineDelimited makes streamers read records separated by blank lines, as written by
+// producers that pretty print each record. Each block of lines must contain exactly one json
+// value. Errors report the number of the block in the file, starting at 1.
+func WithBlankLineDelimited() Option {
+	return func(o *options) {
+		o.blankLines = true
+	}
+}
+
+// blockDecoder decodes one json value per block of lines.
+type blockDecoder struct {
+	br    *bufio.Reader
+	block []byte
+	n     int   // number of blocks read
+	off   int64 // bytes read so far
+}
+
+func newBlockDecoder(r io.Reader) *blockDecoder {
+	return &blockDecoder{br: bufio.NewReader(r)}
+}
+
+func (d *blockDecoder) Decode(v interface{}) error {
+	d.block = d.block[:0]
+	for {
+		line, err := d.br.ReadBytes('\n')
+		if err != nil && err != io.EOF {
+			return err
+		}
+		d.off += int64(len(line))
+		blank := len(bytes.TrimSpace(line)) == 0
+		if !blank {
+			d.block = append(d.block, line...)
+		}
+		if (blank || err == io.EOF) && len(d.block) > 0 {
+			d.n++
+			e := DefaultCodec.Unmarshal(d.block, v)
+			if e != nil {
+				return fmt.Errorf("block %d: %w", d.n, e)
+			}
+			return nil
+		}
+		if err == io.EOF {
+			return io.EOF
+		}
+	}
+}
+
+// InputOffset returns the offset of the end of the last line read.
+func (d *blockDecoder) InputOffset() int64 {
+	return d.off
+}
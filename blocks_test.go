@@ -0,0 +1,72 @@
+// Copyright (c) 2015 AKUALAB INC., All rights reserved.
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ju
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestBlankLineDelimited(t *testing.T) {
+
+	fn := filepath.Join(os.TempDir(), "blocks", "b.json")
+	e := os.MkdirAll(filepath.Dir(fn), 0777)
+	if e != nil {
+		t.Fatal(e)
+	}
+	data := `
+
+{
+  "Name": "a",
+  "N": 1
+}
+
+  	
+{
+  "N": 2,
+  "Words": [
+    "x"
+  ]
+}
+{"N": 3}
+
+{"N": 4}`
+	e = os.WriteFile(fn, []byte(data), 0644)
+	if e != nil {
+		t.Fatal(e)
+	}
+
+	js, err := NewJSONStreamer(fn, WithBlankLineDelimited())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer js.Close()
+	for _, expected := range []tt{{Name: "a", N: 1}} {
+		var x tt
+		e := js.Next(&x)
+		if e != nil {
+			t.Fatal(e)
+		}
+		if !x.equal(expected) {
+			t.Fatalf("expected %v, got %v", expected, x)
+		}
+	}
+	// The second block has two values.
+	var x tt
+	e = js.Next(&x)
+	if e == nil || !strings.Contains(e.Error(), "block 2") {
+		t.Fatalf("expected error in block 2, got %v", e)
+	}
+	e = js.Next(&x)
+	if e != nil || x.N != 4 {
+		t.Fatalf("expected N=4, got %v, %v", x, e)
+	}
+	if e = js.Next(&x); e != Done {
+		t.Fatalf("expected Done, got %v", e)
+	}
+}
@@ -0,0 +1,45 @@
+// Copyright (c) 2015 AKUALAB INC., All rights reserved.
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ju
+
+import (
+	"bytes"
+	"io"
+)
+
+var utf8BOM = []byte{0xEF, 0xBB, 0xBF}
+
+// bomReader removes a UTF-8 byte order mark from the beginning of a file.
+type bomReader struct {
+	rc      io.ReadCloser
+	checked bool
+	buf     []byte // bytes read while checking for the mark
+}
+
+func (b *bomReader) Read(p []byte) (int, error) {
+	if !b.checked {
+		b.checked = true
+		head := make([]byte, len(utf8BOM))
+		n, err := io.ReadFull(b.rc, head)
+		head = head[:n]
+		if !bytes.Equal(head, utf8BOM) {
+			b.buf = head
+		}
+		if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+			return 0, err
+		}
+	}
+	if len(b.buf) > 0 {
+		n := copy(p, b.buf)
+		b.buf = b.buf[n:]
+		return n, nil
+	}
+	return b.rc.Read(p)
+}
+
+func (b *bomReader) Close() error {
+	return b.rc.Close()
+}
@@ -0,0 +1,58 @@
+// Copyright (c) 2015 AKUALAB INC., All rights reserved.
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ju
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestBOM(t *testing.T) {
+
+	dir := filepath.Join(os.TempDir(), "bom")
+	os.RemoveAll(dir)
+	e := os.MkdirAll(dir, 0777)
+	if e != nil {
+		t.Fatal(e)
+	}
+	files := map[string]string{
+		"a.json": "\xEF\xBB\xBF{\"N\":1}\n",
+		"b.json": "{\"N\":2}",
+		"c.json": "\xEF\xBB",
+		"d.json": "",
+		"e.json": "\xEF\xBB\xBF",
+	}
+	for name, data := range files {
+		e := os.WriteFile(filepath.Join(dir, name), []byte(data), 0644)
+		if e != nil {
+			t.Fatal(e)
+		}
+	}
+
+	rc, err := FileStreamer(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := io.ReadAll(rc)
+	rc.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "{\"N\":1}\n{\"N\":2}\xEF\xBB" {
+		t.Fatalf("unexpected data %q", data)
+	}
+
+	os.Remove(filepath.Join(dir, "c.json"))
+	objs, err := CollectParallel[tt](dir, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(objs) != 2 {
+		t.Fatalf("expected 2 objects, got %v", objs)
+	}
+}
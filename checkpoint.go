@@ -0,0 +1,78 @@
+// Copyright (c) 2015 AKUALAB INC., All rights reserved.
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ju
+
+import (
+	"errors"
+	"fmt"
+	"io"
+)
+
+// Token is an opaque position in a stream returned by JSONStreamer.Checkpoint.
+// It can be stored, for example in a file, and passed to JSONStreamer.ResumeFrom.
+type Token []byte
+
+// checkpoint is the content of a Token.
+type checkpoint struct {
+	File    int    // index of the current file, -1 before the first file
+	Name    string // name of the current file
+	Objects int64  // objects decoded from the current file
+}
+
+// Checkpoint returns the position of the streamer after the last object returned by Next.
+// A new streamer for the same path and options can continue from that position with ResumeFrom.
+func (js *JSONStreamer) Checkpoint() (Token, error) {
+	if js.m == nil {
+		return nil, errors.New("cannot checkpoint a reader")
+	}
+	return DefaultCodec.Marshal(checkpoint{File: js.files - 1, Name: js.m.name, Objects: js.fileObjs})
+}
+
+// ResumeFrom moves a new streamer to the position returned by Checkpoint, so that the next call
+// to Next returns the object that follows. It must be called before Next. The files before the
+// position are skipped without reading them. The objects before the position in its file are
+// read and discarded, since compressed files cannot be seeked. The files must not change between
+// the checkpoint and the resume; the name of the file is checked. Objects skipped with WithSkip
+// are already behind a checkpoint taken after the first call to Next and are not skipped again.
+func (js *JSONStreamer) ResumeFrom(t Token) error {
+	if js.m == nil {
+		return errors.New("cannot resume a reader")
+	}
+	if js.files > 0 || js.n > 0 {
+		return errors.New("ResumeFrom must be called before Next")
+	}
+	var c checkpoint
+	err := DefaultCodec.Unmarshal(t, &c)
+	if err != nil {
+		return fmt.Errorf("invalid checkpoint token: %w", err)
+	}
+	for js.files <= c.File {
+		err := js.openNext()
+		if err == io.EOF {
+			return fmt.Errorf("cannot resume: checkpoint file %d not found", c.File)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	if c.File >= 0 && js.m.name != c.Name {
+		return fmt.Errorf("cannot resume: checkpoint file %d is %s, found %s", c.File, c.Name, js.m.name)
+	}
+	if c.File >= 0 {
+		js.skip = 0
+	}
+	for js.fileObjs < c.Objects {
+		err := js.decodeNext(&js.raw)
+		if err == io.EOF {
+			js.done = true
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,86 @@
+// Copyright (c) 2015 AKUALAB INC., All rights reserved.
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ju
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCheckpoint(t *testing.T) {
+
+	dir := filepath.Join(os.TempDir(), "checkpoint")
+	os.RemoveAll(dir)
+	for i, fn := range []string{"a.json", "b.json.gz", "c.json"} {
+		data := make([]tt, 5)
+		for j := range data {
+			data[j].N = i*5 + j
+		}
+		e := WriteAll(filepath.Join(dir, fn), data)
+		if e != nil {
+			t.Fatal(e)
+		}
+	}
+
+	for _, stop := range []int{0, 3, 5, 7, 15} {
+		js, err := NewJSONStreamer(dir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i := 0; i < stop; i++ {
+			var x tt
+			e := js.Next(&x)
+			if e != nil {
+				t.Fatal(e)
+			}
+		}
+		tok, err := js.Checkpoint()
+		if err != nil {
+			t.Fatal(err)
+		}
+		js.Close()
+
+		js, err = NewJSONStreamer(dir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		e := js.ResumeFrom(tok)
+		if e != nil {
+			t.Fatal(e)
+		}
+		n := stop
+		for {
+			var x tt
+			e := js.Next(&x)
+			if e == Done {
+				break
+			}
+			if e != nil {
+				t.Fatal(e)
+			}
+			if x.N != n {
+				t.Fatalf("resumed after %d objects: expected object %d, got %d", stop, n, x.N)
+			}
+			n++
+		}
+		js.Close()
+		if n != 15 {
+			t.Fatalf("resumed after %d objects: expected 15 objects, got %d", stop, n)
+		}
+	}
+
+	// The token does not match the files.
+	js, err := NewJSONStreamer(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer js.Close()
+	e := js.ResumeFrom(Token(`{"File":1,"Name":"x.json","Objects":2}`))
+	if e == nil {
+		t.Fatal("expected an error for a token that does not match the files")
+	}
+}
@@ -0,0 +1,74 @@
+// Copyright (c) 2015 AKUALAB INC., All rights reserved.
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ju
+
+import (
+	"encoding/json"
+	"io"
+)
+
+// Codec marshals and unmarshals json objects. The package uses DefaultCodec to read and
+// write json objects, with these exceptions, which use encoding/json directly because they
+// need features that the Codec interface does not provide, such as json.Decoder.Token,
+// UseNumber, InputOffset, or sorted map keys:
+//
+//   - parsing that keeps the order of object members or the text of numbers: OrderedMap.UnmarshalJSON,
+//     MergePatch and ApplyMergePatch (decoding only), CompileSchema and Schema.Validate,
+//     WithRejectDuplicateKeys, WithSortedKeys, InferSchema, GenerateStruct, and ConvertStream
+//     to MsgPack;
+//   - byte offsets: BuildIndex and FollowJSON;
+//   - canonical encoding: Diff hashes objects encoded by encoding/json;
+//   - reformatting raw json: ConvertStream to JSONLines (json.Compact), PrettyPrint (json.Indent),
+//     and the detection of numbers by StreamFromCSV (json.Valid).
+//
+// Faster json libraries typically provide the same API as encoding/json and
+// only need a thin adapter. For example, using jsoniter:
+//
+//	type jsoniterCodec struct{ api jsoniter.API }
+//
+//	func (c jsoniterCodec) Marshal(v interface{}) ([]byte, error)      { return c.api.Marshal(v) }
+//	func (c jsoniterCodec) Unmarshal(data []byte, v interface{}) error { return c.api.Unmarshal(data, v) }
+//	func (c jsoniterCodec) NewEncoder(w io.Writer) ju.Encoder          { return c.api.NewEncoder(w) }
+//	func (c jsoniterCodec) NewDecoder(r io.Reader) ju.Decoder          { return c.api.NewDecoder(r) }
+//
+//	ju.DefaultCodec = jsoniterCodec{jsoniter.ConfigCompatibleWithStandardLibrary}
+type Codec interface {
+	Marshal(v interface{}) ([]byte, error)
+	Unmarshal(data []byte, v interface{}) error
+	NewEncoder(w io.Writer) Encoder
+	NewDecoder(r io.Reader) Decoder
+}
+
+// Encoder writes json objects to an output stream.
+type Encoder interface {
+	Encode(v interface{}) error
+}
+
+// Decoder reads json objects from an input stream.
+// Decode must return io.EOF when the input stream is exhausted.
+type Decoder interface {
+	Decode(v interface{}) error
+}
+
+// DefaultCodec is the codec used by the package. It defaults to StdCodec.
+// It is not safe to change DefaultCodec concurrently with its use; set it
+// once during initialization.
+var DefaultCodec Codec = StdCodec{}
+
+// StdCodec is a Codec that uses the standard library package encoding/json.
+type StdCodec struct{}
+
+// Marshal calls json.Marshal.
+func (StdCodec) Marshal(v interface{}) ([]byte, error) { return json.Marshal(v) }
+
+// Unmarshal calls json.Unmarshal.
+func (StdCodec) Unmarshal(data []byte, v interface{}) error { return json.Unmarshal(data, v) }
+
+// NewEncoder returns a *json.Encoder.
+func (StdCodec) NewEncoder(w io.Writer) Encoder { return json.NewEncoder(w) }
+
+// NewDecoder returns a *json.Decoder.
+func (StdCodec) NewDecoder(r io.Reader) Decoder { return json.NewDecoder(r) }
@@ -0,0 +1,94 @@
+// Copyright (c) 2015 AKUALAB INC., All rights reserved.
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ju
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+type countingCodec struct {
+	StdCodec
+	n *int
+}
+
+func (c countingCodec) NewEncoder(w io.Writer) Encoder { *c.n++; return c.StdCodec.NewEncoder(w) }
+func (c countingCodec) NewDecoder(r io.Reader) Decoder { *c.n++; return c.StdCodec.NewDecoder(r) }
+
+func TestCodec(t *testing.T) {
+
+	var n int
+	DefaultCodec = countingCodec{n: &n}
+	defer func() { DefaultCodec = StdCodec{} }()
+
+	fn := filepath.Join(os.TempDir(), "codec", "codec.json")
+	objs := []tt{{Name: "a", N: 1}, {Name: "b", N: 2}}
+	e := WriteAll(fn, objs)
+	if e != nil {
+		t.Fatal(e)
+	}
+	js, err := NewJSONStreamer(fn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; ; i++ {
+		var o tt
+		e := js.Next(&o)
+		if e == Done {
+			break
+		}
+		if e != nil {
+			t.Fatal(e)
+		}
+		if !objs[i].equal(o) {
+			t.Fatalf("mismatch, expected %v, got %v", objs[i], o)
+		}
+	}
+	js.Close()
+	if n != 2 {
+		t.Fatalf("expected codec to create 2 encoders/decoders, got %d", n)
+	}
+}
+
+// marshalCodec counts the calls to Marshal and Unmarshal.
+type marshalCodec struct {
+	StdCodec
+	n *int
+}
+
+func (c marshalCodec) Marshal(v interface{}) ([]byte, error) { *c.n++; return c.StdCodec.Marshal(v) }
+func (c marshalCodec) Unmarshal(data []byte, v interface{}) error {
+	*c.n++
+	return c.StdCodec.Unmarshal(data, v)
+}
+
+func TestCodecHelpers(t *testing.T) {
+
+	var n int
+	DefaultCodec = marshalCodec{n: &n}
+	defer func() { DefaultCodec = StdCodec{} }()
+
+	raw, err := Select([]byte(`{"a":[1,{"b":2}]}`), "a[1].b")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(raw) != "2" || n != 3 {
+		t.Fatalf("expected 2 and 3 codec calls, got %s and %d", raw, n)
+	}
+
+	n = 0
+	om := NewOrderedMap()
+	om.Set("k", "v")
+	data, err := om.MarshalJSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != `{"k":"v"}` || n != 2 {
+		t.Fatalf("expected {\"k\":\"v\"} and 2 codec calls, got %s and %d", data, n)
+	}
+}
@@ -0,0 +1,199 @@
+// Copyright (c) 2015 AKUALAB INC., All rights reserved.
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ju
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"path/filepath"
+	"strconv"
+
+	gzip "github.com/klauspost/pgzip"
+)
+
+// Format is an output format for ConvertStream.
+type Format int
+
+const (
+	// JSONLines writes one compact json object per line.
+	JSONLines Format = iota
+	// MsgPack writes each object as a MessagePack value. The values are concatenated
+	// without delimiters, as MessagePack stream decoders expect.
+	MsgPack
+)
+
+func (f Format) String() string {
+	switch f {
+	case JSONLines:
+		return "jsonlines"
+	case MsgPack:
+		return "msgpack"
+	}
+	return "Format(" + strconv.Itoa(int(f)) + ")"
+}
+
+// ConvertStream reads the json values in srcPath and writes them to dstPath in the given format.
+// If the ext of dstPath is "gz", the output is gzipped. Object fields keep their order. Integers
+// are encoded as MessagePack integers and other numbers as 64-bit floats.
+// See FileStreamer for srcPath and ext.
+func ConvertStream(srcPath, dstPath string, format Format, ext ...string) error {
+
+	var encode func(buf []byte, raw json.RawMessage) ([]byte, error)
+	switch format {
+	case JSONLines:
+		encode = func(buf []byte, raw json.RawMessage) ([]byte, error) {
+			b := bytes.NewBuffer(buf)
+			err := json.Compact(b, raw)
+			if err != nil {
+				return nil, err
+			}
+			return append(b.Bytes(), '\n'), nil
+		}
+	case MsgPack:
+		encode = func(buf []byte, raw json.RawMessage) ([]byte, error) {
+			v, err := decodeOrdered(raw)
+			if err != nil {
+				return nil, err
+			}
+			return appendMsgPack(buf, v)
+		}
+	default:
+		return fmt.Errorf("unknown format %v", format)
+	}
+
+	f, err := newOptions().create(dstPath)
+	if err != nil {
+		return err
+	}
+	var w io.Writer = f
+	var gz *gzip.Writer
+	if filepath.Ext(dstPath) == ".gz" {
+		gz = gzip.NewWriter(f)
+		w = gz
+	}
+	var buf []byte
+	err = forEachRaw(srcPath, ext, func(raw json.RawMessage) error {
+		var err error
+		buf, err = encode(buf[:0], raw)
+		if err != nil {
+			return err
+		}
+		_, err = w.Write(buf)
+		return err
+	})
+	if err == nil && gz != nil {
+		err = gz.Close()
+	}
+	if err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// appendMsgPack appends the MessagePack encoding of a value returned by decodeOrdered.
+func appendMsgPack(b []byte, v interface{}) ([]byte, error) {
+	var err error
+	switch t := v.(type) {
+	case nil:
+		return append(b, 0xc0), nil
+	case bool:
+		if t {
+			return append(b, 0xc3), nil
+		}
+		return append(b, 0xc2), nil
+	case string:
+		return appendMsgPackString(b, t), nil
+	case json.Number:
+		if i, err := t.Int64(); err == nil {
+			return appendMsgPackInt(b, i), nil
+		}
+		if u, err := strconv.ParseUint(t.String(), 10, 64); err == nil {
+			return binary.BigEndian.AppendUint64(append(b, 0xcf), u), nil
+		}
+		f, err := t.Float64()
+		if err != nil {
+			return nil, err
+		}
+		return binary.BigEndian.AppendUint64(append(b, 0xcb), math.Float64bits(f)), nil
+	case []interface{}:
+		b = appendMsgPackHeader(b, len(t), 0x90, 0xdc)
+		for _, x := range t {
+			b, err = appendMsgPack(b, x)
+			if err != nil {
+				return nil, err
+			}
+		}
+		return b, nil
+	case *OrderedMap:
+		b = appendMsgPackHeader(b, t.Len(), 0x80, 0xde)
+		for _, k := range t.Keys() {
+			b = appendMsgPackString(b, k)
+			x, _ := t.Get(k)
+			b, err = appendMsgPack(b, x)
+			if err != nil {
+				return nil, err
+			}
+		}
+		return b, nil
+	}
+	return nil, fmt.Errorf("cannot encode %T as msgpack", v)
+}
+
+func appendMsgPackInt(b []byte, i int64) []byte {
+	switch {
+	case i >= 0 && i <= math.MaxInt8:
+		return append(b, byte(i))
+	case i < 0 && i >= -32:
+		return append(b, byte(i))
+	case i >= 0 && i <= math.MaxUint8:
+		return append(b, 0xcc, byte(i))
+	case i >= 0 && i <= math.MaxUint16:
+		return binary.BigEndian.AppendUint16(append(b, 0xcd), uint16(i))
+	case i >= 0 && i <= math.MaxUint32:
+		return binary.BigEndian.AppendUint32(append(b, 0xce), uint32(i))
+	case i >= 0:
+		return binary.BigEndian.AppendUint64(append(b, 0xcf), uint64(i))
+	case i >= math.MinInt8:
+		return append(b, 0xd0, byte(i))
+	case i >= math.MinInt16:
+		return binary.BigEndian.AppendUint16(append(b, 0xd1), uint16(i))
+	case i >= math.MinInt32:
+		return binary.BigEndian.AppendUint32(append(b, 0xd2), uint32(i))
+	}
+	return binary.BigEndian.AppendUint64(append(b, 0xd3), uint64(i))
+}
+
+func appendMsgPackString(b []byte, s string) []byte {
+	n := len(s)
+	switch {
+	case n < 32:
+		b = append(b, 0xa0|byte(n))
+	case n <= math.MaxUint8:
+		b = append(b, 0xd9, byte(n))
+	case n <= math.MaxUint16:
+		b = binary.BigEndian.AppendUint16(append(b, 0xda), uint16(n))
+	default:
+		b = binary.BigEndian.AppendUint32(append(b, 0xdb), uint32(n))
+	}
+	return append(b, s...)
+}
+
+// appendMsgPackHeader appends the header of an array or a map with n elements. fix is the
+// code of the fixed size format; the 16 and 32 bit formats are code16 and code16+1.
+func appendMsgPackHeader(b []byte, n int, fix, code16 byte) []byte {
+	switch {
+	case n < 16:
+		return append(b, fix|byte(n))
+	case n <= math.MaxUint16:
+		return binary.BigEndian.AppendUint16(append(b, code16), uint16(n))
+	}
+	return binary.BigEndian.AppendUint32(append(b, code16+1), uint32(n))
+}
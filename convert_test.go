@@ -0,0 +1,123 @@
+// Copyright (c) 2015 AKUALAB INC., All rights reserved.
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ju
+
+import (
+	"bytes"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestAppendMsgPack(t *testing.T) {
+
+	for _, c := range []struct {
+		json string
+		hex  string
+	}{
+		{`null`, "c0"},
+		{`true`, "c3"},
+		{`false`, "c2"},
+		{`0`, "00"},
+		{`127`, "7f"},
+		{`128`, "cc80"},
+		{`65536`, "ce00010000"},
+		{`-1`, "ff"},
+		{`-33`, "d0df"},
+		{`-129`, "d1ff7f"},
+		{`18446744073709551615`, "cfffffffffffffffff"},
+		{`1.5`, "cb3ff8000000000000"},
+		{`"abc"`, "a3616263"},
+		{`"` + strings.Repeat("x", 32) + `"`, "d920" + strings.Repeat("78", 32)},
+		{`[1,"a",[]]`, "9301a16190"},
+		{`{"b":1,"a":{"c":null}}`, "82a16201a16181a163c0"},
+	} {
+		v, err := decodeOrdered([]byte(c.json))
+		if err != nil {
+			t.Fatal(err)
+		}
+		b, err := appendMsgPack(nil, v)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if hex.EncodeToString(b) != c.hex {
+			t.Fatalf("%s: expected %s, got %x", c.json, c.hex, b)
+		}
+	}
+}
+
+func TestConvertStream(t *testing.T) {
+
+	dir := filepath.Join(os.TempDir(), "convert")
+	os.RemoveAll(dir)
+	src := filepath.Join(dir, "src.json.gz")
+	e := WriteAll(src, []tt{{Name: "a", N: 1}, {Name: "b", N: 300, Words: []string{"x"}}})
+	if e != nil {
+		t.Fatal(e)
+	}
+
+	dst := filepath.Join(dir, "out.msgpack")
+	e = ConvertStream(src, dst, MsgPack)
+	if e != nil {
+		t.Fatal(e)
+	}
+	b, err := os.ReadFile(dst)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected, _ := hex.DecodeString("83a44e616d65a161a14e01a5576f726473c0" + "83a44e616d65a162a14ecd012ca5576f72647391a178")
+	if !bytes.Equal(b, expected) {
+		t.Fatalf("expected %x, got %x", expected, b)
+	}
+
+	dst = filepath.Join(dir, "out.json.gz")
+	e = ConvertStream(src, dst, JSONLines)
+	if e != nil {
+		t.Fatal(e)
+	}
+	objs, err := CollectParallel[tt](dst, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(objs) != 2 || objs[1].N != 300 {
+		t.Fatalf("unexpected objects %v", objs)
+	}
+
+	e = ConvertStream(src, dst, Format(99))
+	if e == nil {
+		t.Fatal("expected error for unknown format")
+	}
+}
+
+func TestConvertStreamCompact(t *testing.T) {
+
+	dir := filepath.Join(os.TempDir(), "convertcompact")
+	os.RemoveAll(dir)
+	e := os.MkdirAll(dir, 0777)
+	if e != nil {
+		t.Fatal(e)
+	}
+	src := filepath.Join(dir, "src.json")
+	e = os.WriteFile(src, []byte("{\n  \"Name\": \"a\",\n  \"Words\": [\n    \"x\", \"y\"\n  ]\n}\n{ \"N\" : 2 }\n"), 0644)
+	if e != nil {
+		t.Fatal(e)
+	}
+	dst := filepath.Join(dir, "out.jsonl")
+	e = ConvertStream(src, dst, JSONLines)
+	if e != nil {
+		t.Fatal(e)
+	}
+	b, err := os.ReadFile(dst)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "{\"Name\":\"a\",\"Words\":[\"x\",\"y\"]}\n{\"N\":2}\n"
+	if string(b) != expected {
+		t.Fatalf("expected %q, got %q", expected, b)
+	}
+}
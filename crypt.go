@@ -0,0 +1,235 @@
+// Copyright (c) 2015 AKUALAB INC., All rights reserved.
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ju
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// Encrypted files start with a header: the magic string "JUE", a version byte,
+// and a random 8-byte nonce prefix. The data follows as a sequence of chunks sealed
+// with AES-GCM, each preceded by its length as a big-endian uint32. The nonce of a chunk
+// is the prefix followed by the chunk number as a big-endian uint32. The last chunk is
+// sealed with additional data 1 (0 for the others) so that truncated files are detected.
+const (
+	cryptMagic     = "JUE"
+	cryptVersion   = 1
+	cryptPrefixLen = 8
+	cryptChunkSize = 64 * 1024
+)
+
+// ErrDecrypt is returned when an encrypted file cannot be decrypted, because the key
+// is wrong or the file is corrupted or truncated.
+var ErrDecrypt = errors.New("cannot decrypt file")
+
+// WithEncryption encrypts the files created by a Writer and decrypts the files read by
+// a streamer using AES-GCM. The key must be 16, 24, or 32 bytes long to select AES-128,
+// AES-192, or AES-256. Data is compressed before it is encrypted.
+//
+// Encryption provides confidentiality and integrity of the file content only. File names
+// and sizes are not protected. Managing keys is the responsibility of the caller.
+// Encryption applies to regular files, not to the entries of zip and tar archives.
+func WithEncryption(key []byte) Option {
+	return func(o *options) {
+		_, err := aes.NewCipher(key)
+		if err != nil {
+			o.err = err
+			return
+		}
+		o.key = key
+	}
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+func cryptNonce(prefix []byte, n uint32) []byte {
+	nonce := make([]byte, 12)
+	copy(nonce, prefix)
+	binary.BigEndian.PutUint32(nonce[cryptPrefixLen:], n)
+	return nonce
+}
+
+var (
+	adMore = []byte{0}
+	adLast = []byte{1}
+)
+
+// encryptWriter encrypts data written to w. Close writes the last chunk but does not close w.
+type encryptWriter struct {
+	w      io.Writer
+	aead   cipher.AEAD
+	prefix []byte
+	n      uint32
+	buf    []byte
+	closed bool
+}
+
+func newEncryptWriter(w io.Writer, key []byte) (*encryptWriter, error) {
+	aead, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	ew := &encryptWriter{w: w, aead: aead, prefix: make([]byte, cryptPrefixLen)}
+	_, err = rand.Read(ew.prefix)
+	if err != nil {
+		return nil, err
+	}
+	hdr := append([]byte(cryptMagic), cryptVersion)
+	hdr = append(hdr, ew.prefix...)
+	_, err = w.Write(hdr)
+	if err != nil {
+		return nil, err
+	}
+	return ew, nil
+}
+
+func (ew *encryptWriter) Write(p []byte) (int, error) {
+	if ew.closed {
+		return 0, errors.New("write to closed encrypted writer")
+	}
+	n := len(p)
+	for len(p) > 0 {
+		k := cryptChunkSize - len(ew.buf)
+		if k > len(p) {
+			k = len(p)
+		}
+		ew.buf = append(ew.buf, p[:k]...)
+		p = p[k:]
+		if len(ew.buf) == cryptChunkSize {
+			err := ew.seal(adMore)
+			if err != nil {
+				return 0, err
+			}
+		}
+	}
+	return n, nil
+}
+
+func (ew *encryptWriter) seal(ad []byte) error {
+	if ew.n == ^uint32(0) {
+		return errors.New("encrypted file too large")
+	}
+	ct := ew.aead.Seal(nil, cryptNonce(ew.prefix, ew.n), ew.buf, ad)
+	ew.n++
+	ew.buf = ew.buf[:0]
+	var size [4]byte
+	binary.BigEndian.PutUint32(size[:], uint32(len(ct)))
+	_, err := ew.w.Write(size[:])
+	if err != nil {
+		return err
+	}
+	_, err = ew.w.Write(ct)
+	return err
+}
+
+// Flush seals the buffered data, if any.
+func (ew *encryptWriter) Flush() error {
+	if len(ew.buf) == 0 || ew.closed {
+		return nil
+	}
+	return ew.seal(adMore)
+}
+
+// Close writes the last chunk.
+func (ew *encryptWriter) Close() error {
+	if ew.closed {
+		return nil
+	}
+	ew.closed = true
+	return ew.seal(adLast)
+}
+
+// decryptReader decrypts data read from r.
+type decryptReader struct {
+	r      io.ReadCloser
+	aead   cipher.AEAD
+	prefix []byte
+	n      uint32
+	buf    []byte
+	last   bool
+}
+
+func newDecryptReader(r io.ReadCloser, key []byte) (*decryptReader, error) {
+	aead, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	hdr := make([]byte, len(cryptMagic)+1+cryptPrefixLen)
+	_, err = io.ReadFull(r, hdr)
+	if err != nil || string(hdr[:len(cryptMagic)]) != cryptMagic {
+		return nil, fmt.Errorf("%w: missing header", ErrDecrypt)
+	}
+	if hdr[len(cryptMagic)] != cryptVersion {
+		return nil, fmt.Errorf("%w: unsupported version %d", ErrDecrypt, hdr[len(cryptMagic)])
+	}
+	return &decryptReader{r: r, aead: aead, prefix: hdr[len(cryptMagic)+1:]}, nil
+}
+
+func (dr *decryptReader) Read(p []byte) (int, error) {
+	for len(dr.buf) == 0 {
+		if dr.last {
+			return 0, io.EOF
+		}
+		err := dr.open()
+		if err != nil {
+			return 0, err
+		}
+	}
+	n := copy(p, dr.buf)
+	dr.buf = dr.buf[n:]
+	return n, nil
+}
+
+// open reads and decrypts the next chunk.
+func (dr *decryptReader) open() error {
+	var size [4]byte
+	_, err := io.ReadFull(dr.r, size[:])
+	if err != nil {
+		return fmt.Errorf("%w: truncated", ErrDecrypt)
+	}
+	n := binary.BigEndian.Uint32(size[:])
+	if n > cryptChunkSize+uint32(dr.aead.Overhead()) {
+		return fmt.Errorf("%w: invalid chunk size", ErrDecrypt)
+	}
+	ct := make([]byte, n)
+	_, err = io.ReadFull(dr.r, ct)
+	if err != nil {
+		return fmt.Errorf("%w: truncated", ErrDecrypt)
+	}
+	nonce := cryptNonce(dr.prefix, dr.n)
+	dr.n++
+	pt, err := dr.aead.Open(nil, nonce, ct, adMore)
+	if err != nil {
+		pt, err = dr.aead.Open(nil, nonce, ct, adLast)
+		if err != nil {
+			return ErrDecrypt
+		}
+		dr.last = true
+		var b [1]byte
+		if k, _ := dr.r.Read(b[:]); k > 0 {
+			return fmt.Errorf("%w: data after last chunk", ErrDecrypt)
+		}
+	}
+	dr.buf = pt
+	return nil
+}
+
+// Close closes the underlying reader.
+func (dr *decryptReader) Close() error {
+	return dr.r.Close()
+}
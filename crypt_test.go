@@ -0,0 +1,112 @@
+// Copyright (c) 2015 AKUALAB INC., All rights reserved.
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ju
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestEncryption(t *testing.T) {
+
+	key := bytes.Repeat([]byte{7}, 32)
+	dir := filepath.Join(os.TempDir(), "crypt")
+	os.RemoveAll(dir)
+	ref := []tt{}
+	for i := 0; i < 5000; i++ {
+		ref = append(ref, tt{Name: "secret@example.com", N: i})
+	}
+	for _, fn := range []string{filepath.Join(dir, "a.json"), filepath.Join(dir, "b.json.gz")} {
+		w, err := NewWriter(fn, WithEncryption(key))
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i := range ref {
+			e := w.Write(&ref[i])
+			if e != nil {
+				t.Fatal(e)
+			}
+			if i == 100 {
+				w.Flush()
+			}
+		}
+		e := w.Close()
+		if e != nil {
+			t.Fatal(e)
+		}
+		data, err := os.ReadFile(fn)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if bytes.Contains(data, []byte("secret")) {
+			t.Fatal("found plain text in encrypted file")
+		}
+
+		js, err := NewJSONStreamer(fn, WithEncryption(key))
+		if err != nil {
+			t.Fatal(err)
+		}
+		i := 0
+		for ; ; i++ {
+			var o tt
+			e := js.Next(&o)
+			if e == Done {
+				break
+			}
+			if e != nil {
+				t.Fatal(e)
+			}
+			if !ref[i].equal(o) {
+				t.Fatalf("mismatch, expected %v, got %v", ref[i], o)
+			}
+		}
+		js.Close()
+		if i != len(ref) {
+			t.Fatalf("expected %d objects, got %d", len(ref), i)
+		}
+
+		// Wrong key.
+		js, err = NewJSONStreamer(fn, WithEncryption(bytes.Repeat([]byte{8}, 32)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		var o tt
+		e = js.Next(&o)
+		if !errors.Is(e, ErrDecrypt) {
+			t.Fatalf("expected ErrDecrypt, got %v", e)
+		}
+		js.Close()
+
+		// Truncated file.
+		e = os.WriteFile(fn, data[:len(data)-100], 0644)
+		if e != nil {
+			t.Fatal(e)
+		}
+		js, err = NewJSONStreamer(fn, WithEncryption(key))
+		if err != nil {
+			t.Fatal(err)
+		}
+		for {
+			var o tt
+			e = js.Next(&o)
+			if e != nil {
+				break
+			}
+		}
+		if !errors.Is(e, ErrDecrypt) {
+			t.Fatalf("expected ErrDecrypt, got %v", e)
+		}
+		js.Close()
+	}
+
+	_, err := NewWriter(filepath.Join(dir, "c.json"), WithEncryption([]byte("short")))
+	if err == nil {
+		t.Fatal("expected invalid key error")
+	}
+}
@@ -0,0 +1,109 @@
+// Copyright (c) 2015 AKUALAB INC., All rights reserved.
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ju
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"io"
+)
+
+// CSVImporter converts CSV files into json objects. The zero value keeps all values as strings.
+type CSVImporter struct {
+	// InferTypes converts cells that are json numbers into numbers and the cells
+	// "true" and "false" into booleans. Other cells, including empty cells, are strings.
+	InferTypes bool
+	// Comma is the field delimiter. Defaults to ','.
+	Comma rune
+}
+
+// StreamFromCSV converts CSV files into json objects using the default CSVImporter.
+func StreamFromCSV(srcPath, dstPath string, ext ...string) error {
+	return CSVImporter{}.StreamFromCSV(srcPath, dstPath, ext...)
+}
+
+// StreamFromCSV reads CSV files from srcPath and writes one json object per row to dstPath.
+// The first row of each file is the header with the field names; the fields of the objects are
+// in the order of the columns. All the rows of a file must have the same number of fields.
+// See FileStreamer for srcPath and ext, and NewWriter for dstPath.
+func (c CSVImporter) StreamFromCSV(srcPath, dstPath string, ext ...string) error {
+
+	m, err := newMulti(srcPath, ext, newOptions())
+	if err != nil {
+		return err
+	}
+	defer m.Close()
+	w, err := NewWriter(dstPath)
+	if err != nil {
+		return err
+	}
+	for {
+		r, err := m.nextFile()
+		if err == io.EOF {
+			break
+		}
+		if err == nil {
+			err = c.convert(r, w)
+		}
+		if err != nil {
+			w.Close()
+			return fileError(m.name, err)
+		}
+	}
+	return w.Close()
+}
+
+// convert writes the rows of one CSV file.
+func (c CSVImporter) convert(r io.Reader, w *Writer) error {
+	cr := csv.NewReader(r)
+	if c.Comma != 0 {
+		cr.Comma = c.Comma
+	}
+	cr.ReuseRecord = true
+	header, err := cr.Read()
+	if err == io.EOF {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	header = append([]string(nil), header...)
+	for {
+		row, err := cr.Read()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		om := NewOrderedMap()
+		for i, cell := range row {
+			om.Set(header[i], c.value(cell))
+		}
+		err = w.Write(om)
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func (c CSVImporter) value(cell string) interface{} {
+	if !c.InferTypes {
+		return cell
+	}
+	switch cell {
+	case "true":
+		return true
+	case "false":
+		return false
+	case "":
+		return cell
+	}
+	if (cell[0] == '-' || (cell[0] >= '0' && cell[0] <= '9')) && json.Valid([]byte(cell)) {
+		return json.Number(cell)
+	}
+	return cell
+}
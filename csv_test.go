@@ -0,0 +1,79 @@
+// Copyright (c) 2015 AKUALAB INC., All rights reserved.
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ju
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestStreamFromCSV(t *testing.T) {
+
+	dir := filepath.Join(os.TempDir(), "csv")
+	os.RemoveAll(dir)
+	src := filepath.Join(dir, "src")
+	e := os.MkdirAll(src, 0777)
+	if e != nil {
+		t.Fatal(e)
+	}
+	e = os.WriteFile(filepath.Join(src, "a.csv"), []byte("name,n,ok,note\nx,1,true,\"a, b\"\ny,-2.5e3,false,007\n"), 0644)
+	if e != nil {
+		t.Fatal(e)
+	}
+	e = os.WriteFile(filepath.Join(src, "b.csv"), []byte("id,n\nz,NaN\n"), 0644)
+	if e != nil {
+		t.Fatal(e)
+	}
+
+	read := func(fn string) string {
+		r, err := FileStreamer(fn)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer r.Close()
+		b, err := io.ReadAll(r)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(b)
+	}
+
+	dst := filepath.Join(dir, "out.json.gz")
+	e = StreamFromCSV(src, dst, ".csv")
+	if e != nil {
+		t.Fatal(e)
+	}
+	expected := `{"name":"x","n":"1","ok":"true","note":"a, b"}
+{"name":"y","n":"-2.5e3","ok":"false","note":"007"}
+{"id":"z","n":"NaN"}
+`
+	if got := read(dst); got != expected {
+		t.Fatalf("expected:\n%s\ngot:\n%s", expected, got)
+	}
+
+	e = CSVImporter{InferTypes: true}.StreamFromCSV(src, dst, ".csv")
+	if e != nil {
+		t.Fatal(e)
+	}
+	expected = `{"name":"x","n":1,"ok":true,"note":"a, b"}
+{"name":"y","n":-2.5e3,"ok":false,"note":"007"}
+{"id":"z","n":"NaN"}
+`
+	if got := read(dst); got != expected {
+		t.Fatalf("expected:\n%s\ngot:\n%s", expected, got)
+	}
+
+	e = os.WriteFile(filepath.Join(src, "c.csv"), []byte("a,b\n1\n"), 0644)
+	if e != nil {
+		t.Fatal(e)
+	}
+	e = StreamFromCSV(src, dst, ".csv")
+	if e == nil {
+		t.Fatal("expected error for wrong number of fields")
+	}
+}
@@ -0,0 +1,107 @@
+// Copyright (c) 2015 AKUALAB INC., All rights reserved.
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ju
+
+import (
+	"encoding/json"
+	"hash/fnv"
+	"math"
+)
+
+// KeySet is a set of keys used to detect duplicates.
+type KeySet interface {
+	// Add adds key to the set and returns true if the key was not in the set.
+	Add(key string) bool
+}
+
+// MapSet is an exact KeySet that keeps all the keys in memory.
+type MapSet map[string]struct{}
+
+// Add implements the KeySet interface.
+func (s MapSet) Add(key string) bool {
+	if _, ok := s[key]; ok {
+		return false
+	}
+	s[key] = struct{}{}
+	return true
+}
+
+// BloomSet is a KeySet backed by a bloom filter. It uses a fixed amount of memory
+// but may report a new key as a duplicate (false positive). It never reports a
+// duplicate key as new.
+type BloomSet struct {
+	bits []uint64
+	m    uint64
+	k    uint64
+}
+
+// NewBloomSet creates a bloom filter sized for n keys with false positive rate p.
+func NewBloomSet(n int, p float64) *BloomSet {
+	if n < 1 {
+		n = 1
+	}
+	if p <= 0 || p >= 1 {
+		p = 0.01
+	}
+	m := uint64(math.Ceil(-float64(n) * math.Log(p) / (math.Ln2 * math.Ln2)))
+	k := uint64(math.Round(float64(m) / float64(n) * math.Ln2))
+	if k < 1 {
+		k = 1
+	}
+	return &BloomSet{bits: make([]uint64, (m+63)/64), m: m, k: k}
+}
+
+// Add implements the KeySet interface.
+func (s *BloomSet) Add(key string) bool {
+	h := fnv.New64a()
+	h.Write([]byte(key))
+	h1 := h.Sum64()
+	h2 := h1>>33 | h1<<31 | 1
+	added := false
+	for i := uint64(0); i < s.k; i++ {
+		b := (h1 + i*h2) % s.m
+		w, mask := b/64, uint64(1)<<(b%64)
+		if s.bits[w]&mask == 0 {
+			s.bits[w] |= mask
+			added = true
+		}
+	}
+	return added
+}
+
+// Dedup reads json objects from srcPath and writes to dstPath only the first object for each key.
+// Keys are computed with keyFn and kept in memory. Returns the number of objects read and written.
+// See FileStreamer for srcPath and ext, and NewWriter for dstPath.
+func Dedup(srcPath, dstPath string, keyFn func(json.RawMessage) (string, error), ext ...string) (total, unique int64, err error) {
+	return DedupSet(srcPath, dstPath, keyFn, MapSet{}, ext...)
+}
+
+// DedupSet is like Dedup but uses set to track the keys. Use a BloomSet to bound memory
+// when the number of keys is huge.
+func DedupSet(srcPath, dstPath string, keyFn func(json.RawMessage) (string, error), set KeySet, ext ...string) (total, unique int64, err error) {
+
+	w, err := NewWriter(dstPath)
+	if err != nil {
+		return 0, 0, err
+	}
+	err = forEachRaw(srcPath, ext, func(raw json.RawMessage) error {
+		total++
+		key, err := keyFn(raw)
+		if err != nil {
+			return err
+		}
+		if !set.Add(key) {
+			return nil
+		}
+		unique++
+		return w.Write(raw)
+	})
+	if err != nil {
+		w.Close()
+		return total, unique, err
+	}
+	return total, unique, w.Close()
+}
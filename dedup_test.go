@@ -0,0 +1,69 @@
+// Copyright (c) 2015 AKUALAB INC., All rights reserved.
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ju
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func nameKey(raw json.RawMessage) (string, error) {
+	var o tt
+	err := json.Unmarshal(raw, &o)
+	return o.Name, err
+}
+
+func TestDedup(t *testing.T) {
+
+	dir := filepath.Join(os.TempDir(), "dedup")
+	os.RemoveAll(dir)
+	for k := 0; k < 3; k++ {
+		objs := []tt{}
+		for i := 0; i < 10; i++ {
+			objs = append(objs, tt{Name: fmt.Sprintf("id-%d", i+k*5), N: k})
+		}
+		e := WriteAll(filepath.Join(dir, "src", fmt.Sprintf("testfile-%d.json", k)), objs)
+		if e != nil {
+			t.Fatal(e)
+		}
+	}
+
+	for _, set := range []KeySet{MapSet{}, NewBloomSet(100, 0.001)} {
+		dst := filepath.Join(dir, "dst.json.gz")
+		total, unique, err := DedupSet(filepath.Join(dir, "src"), dst, nameKey, set, ".json")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if total != 30 || unique != 20 {
+			t.Fatalf("expected 30 total and 20 unique, got %d and %d", total, unique)
+		}
+		js, err := NewJSONStreamer(dst)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i := 0; ; i++ {
+			var o tt
+			e := js.Next(&o)
+			if e == Done {
+				break
+			}
+			if e != nil {
+				t.Fatal(e)
+			}
+			n := 0
+			if i >= 10 {
+				n = (i - 5) / 5
+			}
+			if o.Name != fmt.Sprintf("id-%d", i) || o.N != n {
+				t.Fatalf("unexpected object %d: %v", i, o)
+			}
+		}
+		js.Close()
+	}
+}
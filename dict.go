@@ -0,0 +1,70 @@
+// Copyright (c) 2015 AKUALAB INC., All rights reserved.
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ju
+
+import (
+	"compress/flate"
+	"fmt"
+	"io"
+	"path/filepath"
+)
+
+// dictExt is the extension of files compressed with a preset dictionary.
+const dictExt = ".zdict"
+
+// WithDictionary compresses the ".zdict" files created by a Writer using a preset DEFLATE
+// dictionary, and decompresses the ".zdict" files read by streamers with the same dictionary.
+// A dictionary of byte sequences that are common in the objects, such as field names,
+// greatly improves the compression of small, similar objects.
+//
+// The files contain a raw DEFLATE stream (see compress/flate), not the gzip format, so they
+// cannot be read with gzip tools; the ".zdict" extension keeps them apart from ".gz" files.
+// Like ".gz", the extension is always allowed when selecting files (see FileStreamer).
+// Writers and readers must use the same dictionary; the stream does not record it, so a
+// different dictionary produces corrupt data or an error. Writing a ".gz" file with a
+// dictionary, or a ".zdict" file without one, is an error. The entries of zip and tar
+// archives are not affected.
+func WithDictionary(dict []byte) Option {
+	return func(o *options) {
+		o.dict = dict
+	}
+}
+
+// checkDictExt returns an error if the extension of path does not match the dictionary option.
+func (o *options) checkDictExt(path string) error {
+	ext := filepath.Ext(path)
+	switch {
+	case ext == dictExt && o.dict == nil:
+		return fmt.Errorf("%s: %s files require WithDictionary", path, dictExt)
+	case ext == ".gz" && o.dict != nil:
+		return fmt.Errorf("%s: files compressed with a dictionary must use the %s extension", path, dictExt)
+	}
+	return nil
+}
+
+// flateReader decompresses a raw DEFLATE stream written with a preset dictionary.
+type flateReader struct {
+	fr io.ReadCloser
+	rc io.ReadCloser
+}
+
+func newFlateReader(rc io.ReadCloser, dict []byte) *flateReader {
+	return &flateReader{fr: flate.NewReaderDict(rc, dict), rc: rc}
+}
+
+func (f *flateReader) Read(p []byte) (int, error) {
+	return f.fr.Read(p)
+}
+
+// Close closes the decompressor and the underlying reader.
+func (f *flateReader) Close() error {
+	err := f.fr.Close()
+	e := f.rc.Close()
+	if err != nil {
+		return err
+	}
+	return e
+}
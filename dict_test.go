@@ -0,0 +1,103 @@
+// Copyright (c) 2015 AKUALAB INC., All rights reserved.
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ju
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestDictionary(t *testing.T) {
+
+	dir := filepath.Join(os.TempDir(), "dict")
+	os.RemoveAll(dir)
+	dict := []byte(`{"Name":"event-type-","N":,"Words":["alpha","beta","gamma"]}`)
+	var ref []tt
+	for i := 0; i < 200; i++ {
+		ref = append(ref, tt{Name: fmt.Sprint("event-type-", i%3), N: i, Words: []string{"alpha", "beta", "gamma"}})
+	}
+	plain := filepath.Join(dir, "plain", "d.json.gz")
+	withDict := filepath.Join(dir, "dict", "d.json.zdict")
+	e := WriteAll(plain, ref[:1])
+	if e != nil {
+		t.Fatal(e)
+	}
+	w, err := NewWriter(withDict, WithDictionary(dict))
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.Write(ref[0])
+	e = w.Flush()
+	if e != nil {
+		t.Fatal(e)
+	}
+	for _, x := range ref[1:] {
+		w.Write(x)
+	}
+	e = w.Close()
+	if e != nil {
+		t.Fatal(e)
+	}
+
+	// A single small object compresses better with the dictionary.
+	small := filepath.Join(dir, "dict", "small.json.zdict")
+	w, err = NewWriter(small, WithDictionary(dict))
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.Write(ref[0])
+	w.Close()
+	ps, _ := os.Stat(plain)
+	ds, _ := os.Stat(small)
+	if ds.Size() >= ps.Size() {
+		t.Fatalf("expected dictionary to reduce size: %d >= %d", ds.Size(), ps.Size())
+	}
+
+	js, err := NewJSONStreamer(filepath.Join(dir, "dict"), WithDictionary(dict), WithMaxFiles(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer js.Close()
+	for i := 0; ; i++ {
+		var x tt
+		e := js.Next(&x)
+		if e == Done {
+			if i != len(ref) {
+				t.Fatalf("expected %d objects, got %d", len(ref), i)
+			}
+			break
+		}
+		if e != nil {
+			t.Fatal(e)
+		}
+		if !x.equal(ref[i]) {
+			t.Fatalf("expected %v, got %v", ref[i], x)
+		}
+	}
+
+	// Without the dictionary the file cannot be read.
+	js, err = NewJSONStreamer(withDict)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer js.Close()
+	var x tt
+	if js.Next(&x) == nil {
+		t.Fatal("expected error reading without the dictionary")
+	}
+
+	// Dictionary files do not use the gzip extension.
+	_, err = NewWriter(filepath.Join(dir, "dict", "bad.json.gz"), WithDictionary(dict))
+	if err == nil {
+		t.Fatal("expected error writing a .gz file with a dictionary")
+	}
+	_, err = NewWriter(filepath.Join(dir, "dict", "bad.json.zdict"))
+	if err == nil {
+		t.Fatal("expected error writing a .zdict file without a dictionary")
+	}
+}
@@ -0,0 +1,85 @@
+// Copyright (c) 2015 AKUALAB INC., All rights reserved.
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ju
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"sort"
+)
+
+// Diff compares the json objects in leftPath and rightPath, matching objects by the key
+// returned by keyFn. It returns the keys found only on the right (added), only on the left
+// (removed), and on both sides with different values (changed). Values are compared after
+// normalization, so the order of the fields and whitespace do not matter; numbers are compared
+// as written. Added and changed keys are in the order of the right side, removed keys are sorted.
+// Keys should be unique; if not, the last object with a key is used.
+//
+// The left side is read first and a map from each key to a hash of its object is kept in memory;
+// the right side is streamed. Put the smaller dataset on the left.
+// See FileStreamer for leftPath, rightPath, and ext.
+func Diff(leftPath, rightPath string, keyFn func(json.RawMessage) (string, error), ext ...string) (added, removed, changed []string, err error) {
+
+	left := map[string][sha256.Size]byte{}
+	err = forEachRaw(leftPath, ext, func(raw json.RawMessage) error {
+		key, sum, err := diffHash(raw, keyFn)
+		if err != nil {
+			return err
+		}
+		left[key] = sum
+		return nil
+	})
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	seen := map[string]bool{}
+	err = forEachRaw(rightPath, ext, func(raw json.RawMessage) error {
+		key, sum, err := diffHash(raw, keyFn)
+		if err != nil {
+			return err
+		}
+		l, ok := left[key]
+		switch {
+		case !ok:
+			if !seen[key] {
+				added = append(added, key)
+			}
+		case l != sum && !seen[key]:
+			changed = append(changed, key)
+		}
+		seen[key] = true
+		return nil
+	})
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	for key := range left {
+		if !seen[key] {
+			removed = append(removed, key)
+		}
+	}
+	sort.Strings(removed)
+	return added, removed, changed, nil
+}
+
+// diffHash returns the key of an object and the hash of its normalized encoding.
+func diffHash(raw json.RawMessage, keyFn func(json.RawMessage) (string, error)) (string, [sha256.Size]byte, error) {
+	key, err := keyFn(raw)
+	if err != nil {
+		return "", [sha256.Size]byte{}, err
+	}
+	v, err := decodeNumbers(raw)
+	if err != nil {
+		return "", [sha256.Size]byte{}, err
+	}
+	// Maps are encoded with sorted keys.
+	data, err := json.Marshal(v)
+	if err != nil {
+		return "", [sha256.Size]byte{}, err
+	}
+	return key, sha256.Sum256(data), nil
+}
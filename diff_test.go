@@ -0,0 +1,66 @@
+// Copyright (c) 2015 AKUALAB INC., All rights reserved.
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ju
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestDiff(t *testing.T) {
+
+	dir := filepath.Join(os.TempDir(), "diff")
+	e := os.MkdirAll(dir, 0777)
+	if e != nil {
+		t.Fatal(e)
+	}
+	left := `{"id":"a","v":1,"w":[1,2]}
+{"id":"b","v":2}
+{"id":"c","v":3}
+{"id":"d","v":{"x":1,"y":2}}
+`
+	right := `{"id":"e","v":5}
+{ "w": [1, 2], "v": 1, "id": "a" }
+{"id":"c","v":30}
+{"id":"d","v":{"y":2,"x":1}}
+{"id":"f"}
+`
+	lp := filepath.Join(dir, "left.json")
+	rp := filepath.Join(dir, "right.json")
+	e = os.WriteFile(lp, []byte(left), 0644)
+	if e != nil {
+		t.Fatal(e)
+	}
+	e = os.WriteFile(rp, []byte(right), 0644)
+	if e != nil {
+		t.Fatal(e)
+	}
+
+	key := func(raw json.RawMessage) (string, error) {
+		var x struct{ ID string }
+		err := json.Unmarshal(raw, &x)
+		return x.ID, err
+	}
+	added, removed, changed, err := Diff(lp, rp, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := fmt.Sprint(added, removed, changed)
+	if got != "[e f] [b] [c]" {
+		t.Fatalf("unexpected diff %s", got)
+	}
+
+	added, removed, changed, err = Diff(lp, lp, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(added)+len(removed)+len(changed) != 0 {
+		t.Fatalf("expected no differences, got %v %v %v", added, removed, changed)
+	}
+}
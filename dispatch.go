@@ -0,0 +1,100 @@
+// Copyright (c) 2015 AKUALAB INC., All rights reserved.
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ju
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+)
+
+// Dispatcher decodes a stream of objects of different types. The type of each object is
+// given by the string value of a discriminator field, such as "kind". Each type is registered
+// with a prototype and a handler; objects are decoded into a new value of the type of the
+// prototype and passed to its handler.
+type Dispatcher struct {
+	field string
+	types map[string]dispatchType
+	err   error // first registration error, returned by Run
+
+	// Fallback is called with the kind and the raw object for objects whose kind is not
+	// registered, including objects without the discriminator field (kind is ""). If nil,
+	// Run fails on these objects.
+	Fallback func(kind string, raw json.RawMessage) error
+}
+
+type dispatchType struct {
+	typ     reflect.Type
+	handler func(interface{}) error
+}
+
+// NewDispatcher creates a dispatcher that uses field as the discriminator.
+func NewDispatcher(field string) *Dispatcher {
+	return &Dispatcher{field: field, types: map[string]dispatchType{}}
+}
+
+// RegisterType registers the type of prototype for objects of the given kind. The prototype
+// may be a value or a pointer; in both cases handler receives a pointer to a new value.
+// Registering a kind again replaces the previous registration. If prototype or handler is nil,
+// the kind is not registered and Run returns the error without reading any object.
+func (d *Dispatcher) RegisterType(kind string, prototype interface{}, handler func(interface{}) error) {
+	var err error
+	switch {
+	case prototype == nil:
+		err = fmt.Errorf("kind %q: prototype is nil", kind)
+	case handler == nil:
+		err = fmt.Errorf("kind %q: handler is nil", kind)
+	}
+	if err != nil {
+		if d.err == nil {
+			d.err = err
+		}
+		return
+	}
+	typ := reflect.TypeOf(prototype)
+	if typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	d.types[kind] = dispatchType{typ: typ, handler: handler}
+}
+
+// Run reads the objects in srcPath and calls the handler of each object. It stops at the first
+// error returned by a handler. It returns the first registration error, if any, without reading
+// srcPath. See FileStreamer for srcPath and ext.
+func (d *Dispatcher) Run(srcPath string, ext ...string) error {
+	if d.err != nil {
+		return d.err
+	}
+	return forEachRaw(srcPath, ext, d.dispatch)
+}
+
+func (d *Dispatcher) dispatch(raw json.RawMessage) error {
+	var fields map[string]json.RawMessage
+	err := DefaultCodec.Unmarshal(raw, &fields)
+	if err != nil {
+		return err
+	}
+	var kind string
+	if k, ok := fields[d.field]; ok {
+		err = DefaultCodec.Unmarshal(k, &kind)
+		if err != nil {
+			return fmt.Errorf("field %q: %w", d.field, err)
+		}
+	}
+	t, ok := d.types[kind]
+	if !ok {
+		if d.Fallback == nil {
+			return fmt.Errorf("no type registered for %s %q", d.field, kind)
+		}
+		return d.Fallback(kind, raw)
+	}
+	v := reflect.New(t.typ).Interface()
+	err = DefaultCodec.Unmarshal(raw, v)
+	if err != nil {
+		return err
+	}
+	return t.handler(v)
+}
@@ -0,0 +1,90 @@
+// Copyright (c) 2015 AKUALAB INC., All rights reserved.
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ju
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+type clickEvent struct {
+	Kind string
+	URL  string
+}
+
+type buyEvent struct {
+	Kind  string
+	Price float64
+}
+
+func TestDispatcher(t *testing.T) {
+
+	fn := filepath.Join(os.TempDir(), "dispatch", "events.json")
+	e := os.MkdirAll(filepath.Dir(fn), 0777)
+	if e != nil {
+		t.Fatal(e)
+	}
+	data := `{"Kind":"click","URL":"a"}
+{"Kind":"buy","Price":2.5}
+{"Kind":"view"}
+{"URL":"b"}
+{"Kind":"click","URL":"c"}
+`
+	e = os.WriteFile(fn, []byte(data), 0644)
+	if e != nil {
+		t.Fatal(e)
+	}
+
+	var log []string
+	d := NewDispatcher("Kind")
+	d.RegisterType("click", clickEvent{}, func(v interface{}) error {
+		log = append(log, "click:"+v.(*clickEvent).URL)
+		return nil
+	})
+	d.RegisterType("buy", &buyEvent{}, func(v interface{}) error {
+		b, _ := json.Marshal(v.(*buyEvent))
+		log = append(log, string(b))
+		return nil
+	})
+	e = d.Run(fn)
+	if e == nil || !strings.Contains(e.Error(), `"view"`) {
+		t.Fatalf("expected error for unknown kind, got %v", e)
+	}
+
+	log = nil
+	d.Fallback = func(kind string, raw json.RawMessage) error {
+		log = append(log, "fallback:"+kind)
+		return nil
+	}
+	e = d.Run(fn)
+	if e != nil {
+		t.Fatal(e)
+	}
+	expected := `click:a,{"Kind":"buy","Price":2.5},fallback:view,fallback:,click:c`
+	if strings.Join(log, ",") != expected {
+		t.Fatalf("expected %s, got %s", expected, strings.Join(log, ","))
+	}
+}
+
+func TestDispatcherRegisterNil(t *testing.T) {
+
+	d := NewDispatcher("Kind")
+	d.RegisterType("click", nil, func(interface{}) error { return nil })
+	e := d.Run(filepath.Join(os.TempDir(), "dispatch-missing"))
+	if e == nil || !strings.Contains(e.Error(), "prototype is nil") {
+		t.Fatalf("expected nil prototype error, got %v", e)
+	}
+
+	d = NewDispatcher("Kind")
+	d.RegisterType("click", clickEvent{}, nil)
+	e = d.Run(filepath.Join(os.TempDir(), "dispatch-missing"))
+	if e == nil || !strings.Contains(e.Error(), "handler is nil") {
+		t.Fatalf("expected nil handler error, got %v", e)
+	}
+}
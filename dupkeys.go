@@ -0,0 +1,87 @@
+// Copyright (c) 2015 AKUALAB INC., All rights reserved.
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ju
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+)
+
+// WithRejectDuplicateKeys makes a JSONStreamer fail on objects that contain the same key more
+// than once, at any depth, instead of keeping the last value as encoding/json does. The error
+// wraps ErrInvalid and identifies the key, so WithSkipInvalid skips these objects.
+// Each object is parsed an extra time, which slows down decoding.
+func WithRejectDuplicateKeys() Option {
+	return func(o *options) {
+		o.filters = append(o.filters, func(raw json.RawMessage) (json.RawMessage, error) {
+			return raw, checkDuplicateKeys(raw)
+		})
+	}
+}
+
+// checkDuplicateKeys returns an error if an object in data has a duplicate key.
+func checkDuplicateKeys(data []byte) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	type frame struct {
+		keys   map[string]bool // nil for arrays
+		path   string
+		expect bool // the next token is a key
+	}
+	var stack []*frame
+	path := func(k string) string {
+		if len(stack) == 0 || stack[len(stack)-1].path == "" {
+			return k
+		}
+		return stack[len(stack)-1].path + "." + k
+	}
+	var key string
+	for {
+		tok, err := dec.Token()
+		if err != nil {
+			if len(stack) == 0 {
+				return nil
+			}
+			return err
+		}
+		var top *frame
+		if len(stack) > 0 {
+			top = stack[len(stack)-1]
+		}
+		if s, ok := tok.(string); ok && top != nil && top.keys != nil && top.expect {
+			key = s
+			if top.keys[key] {
+				return fmt.Errorf("%w: duplicate key %q", ErrInvalid, path(key))
+			}
+			top.keys[key] = true
+			top.expect = false
+			continue
+		}
+		switch tok {
+		case json.Delim('{'), json.Delim('['):
+			f := &frame{}
+			if top != nil && top.keys != nil {
+				f.path = path(key)
+			} else if top != nil {
+				f.path = top.path + "[]"
+			}
+			if tok == json.Delim('{') {
+				f.keys = map[string]bool{}
+				f.expect = true
+			}
+			stack = append(stack, f)
+			continue
+		case json.Delim('}'), json.Delim(']'):
+			stack = stack[:len(stack)-1]
+		}
+		if len(stack) > 0 && stack[len(stack)-1].keys != nil {
+			stack[len(stack)-1].expect = true
+		}
+		if len(stack) == 0 {
+			return nil
+		}
+	}
+}
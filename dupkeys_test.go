@@ -0,0 +1,91 @@
+// Copyright (c) 2015 AKUALAB INC., All rights reserved.
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ju
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCheckDuplicateKeys(t *testing.T) {
+
+	for _, c := range []struct {
+		data string
+		key  string
+	}{
+		{`{"a":1,"b":{"a":2},"c":[{"a":1},{"a":2}]}`, ""},
+		{`{}`, ""},
+		{`[1,{"x":[]},"x"]`, ""},
+		{`{"a":"a","b":"a"}`, ""},
+		{`{"a":1,"a":2}`, `"a"`},
+		{`{"a":{"b":{},"c":1,"b":[]}}`, `"a.b"`},
+		{`{"a":[{"x":1},{"y":1,"y":2}]}`, `"a[].y"`},
+		{`[[{"k":{"z":0},"k":1}]]`, `"[][].k"`},
+	} {
+		err := checkDuplicateKeys([]byte(c.data))
+		if c.key == "" {
+			if err != nil {
+				t.Fatalf("%s: unexpected error %v", c.data, err)
+			}
+			continue
+		}
+		if !errors.Is(err, ErrInvalid) || !strings.Contains(err.Error(), c.key) {
+			t.Fatalf("%s: expected duplicate key %s, got %v", c.data, c.key, err)
+		}
+	}
+}
+
+func TestRejectDuplicateKeys(t *testing.T) {
+
+	fn := filepath.Join(os.TempDir(), "dupkeys", "d.json")
+	e := os.MkdirAll(filepath.Dir(fn), 0777)
+	if e != nil {
+		t.Fatal(e)
+	}
+	e = os.WriteFile(fn, []byte("{\"N\":1}\n{\"N\":2,\"N\":3}\n{\"N\":4}\n"), 0644)
+	if e != nil {
+		t.Fatal(e)
+	}
+
+	js, err := NewJSONStreamer(fn, WithRejectDuplicateKeys())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer js.Close()
+	var x tt
+	e = js.Next(&x)
+	if e != nil {
+		t.Fatal(e)
+	}
+	e = js.Next(&x)
+	if !errors.Is(e, ErrInvalid) {
+		t.Fatalf("expected ErrInvalid, got %v", e)
+	}
+
+	js, err = NewJSONStreamer(fn, WithRejectDuplicateKeys(), WithSkipInvalid())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer js.Close()
+	var ns []int
+	for {
+		var x tt
+		e := js.Next(&x)
+		if e == Done {
+			break
+		}
+		if e != nil {
+			t.Fatal(e)
+		}
+		ns = append(ns, x.N)
+	}
+	if len(ns) != 2 || ns[0] != 1 || ns[1] != 4 {
+		t.Fatalf("expected [1 4], got %v", ns)
+	}
+}
@@ -0,0 +1,109 @@
+// Copyright (c) 2015 AKUALAB INC., All rights reserved.
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ju
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+)
+
+// Flattener converts nested objects into flat objects whose keys are the paths
+// to the leaf values, for example {"a":{"b":1}} becomes {"a.b":1}.
+// The zero value uses "." as the separator and indexes arrays.
+type Flattener struct {
+	// Separator between path elements. Defaults to ".".
+	Separator string
+	// EncodeArrays keeps arrays as json encoded strings instead of flattening
+	// them into indexed keys such as "a.0" and "a.1".
+	EncodeArrays bool
+}
+
+// Flatten flattens nested objects using the default Flattener.
+func Flatten(src map[string]interface{}) map[string]interface{} {
+	return Flattener{}.Flatten(src)
+}
+
+// FlattenFile reads json objects from src, flattens them using the default Flattener,
+// and writes them to dst. See FileStreamer for src and NewWriter for dst.
+func FlattenFile(src, dst string) error {
+	return Flattener{}.FlattenFile(src, dst)
+}
+
+// Flatten returns a flat copy of src. Empty objects and arrays are kept as values.
+func (f Flattener) Flatten(src map[string]interface{}) map[string]interface{} {
+	dst := map[string]interface{}{}
+	f.flatten(dst, "", src)
+	return dst
+}
+
+func (f Flattener) flatten(dst map[string]interface{}, prefix string, v interface{}) {
+	sep := f.Separator
+	if sep == "" {
+		sep = "."
+	}
+	key := func(k string) string {
+		if prefix == "" {
+			return k
+		}
+		return prefix + sep + k
+	}
+	switch t := v.(type) {
+	case map[string]interface{}:
+		if len(t) == 0 && prefix != "" {
+			dst[prefix] = t
+			return
+		}
+		for k, x := range t {
+			f.flatten(dst, key(k), x)
+		}
+	case []interface{}:
+		if f.EncodeArrays {
+			b, err := DefaultCodec.Marshal(t)
+			if err != nil {
+				dst[prefix] = fmt.Sprint(t)
+				return
+			}
+			dst[prefix] = string(b)
+			return
+		}
+		if len(t) == 0 {
+			dst[prefix] = t
+			return
+		}
+		for i, x := range t {
+			f.flatten(dst, key(strconv.Itoa(i)), x)
+		}
+	default:
+		dst[prefix] = v
+	}
+}
+
+// FlattenFile reads json objects from src, flattens them, and writes them to dst.
+// Numbers are preserved exactly. See FileStreamer for src and NewWriter for dst.
+func (f Flattener) FlattenFile(src, dst string) error {
+
+	w, err := NewWriter(dst)
+	if err != nil {
+		return err
+	}
+	err = forEachRaw(src, nil, func(raw json.RawMessage) error {
+		v, err := decodeNumbers(raw)
+		if err != nil {
+			return err
+		}
+		m, ok := v.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("cannot flatten %s, not an object", jsonType(v))
+		}
+		return w.Write(f.Flatten(m))
+	})
+	if err != nil {
+		w.Close()
+		return err
+	}
+	return w.Close()
+}
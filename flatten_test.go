@@ -0,0 +1,70 @@
+// Copyright (c) 2015 AKUALAB INC., All rights reserved.
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ju
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFlatten(t *testing.T) {
+
+	var src map[string]interface{}
+	e := json.Unmarshal([]byte(`{"a":{"b":{"c":1},"d":[1,{"e":2}]},"f":"x","g":{}}`), &src)
+	if e != nil {
+		t.Fatal(e)
+	}
+	b, _ := json.Marshal(Flatten(src))
+	expected := `{"a.b.c":1,"a.d.0":1,"a.d.1.e":2,"f":"x","g":{}}`
+	if string(b) != expected {
+		t.Fatalf("expected %s, got %s", expected, b)
+	}
+	b, _ = json.Marshal(Flattener{Separator: "_", EncodeArrays: true}.Flatten(src))
+	expected = `{"a_b_c":1,"a_d":"[1,{\"e\":2}]","f":"x","g":{}}`
+	if string(b) != expected {
+		t.Fatalf("expected %s, got %s", expected, b)
+	}
+}
+
+func TestFlattenFile(t *testing.T) {
+
+	dir := filepath.Join(os.TempDir(), "flatten")
+	e := os.MkdirAll(dir, 0777)
+	if e != nil {
+		t.Fatal(e)
+	}
+	src := filepath.Join(dir, "src.json")
+	e = os.WriteFile(src, []byte(`{"a":{"b":12345678901234567890}}`+"\n"+`{"c":[true]}`+"\n"), 0644)
+	if e != nil {
+		t.Fatal(e)
+	}
+	dst := filepath.Join(dir, "dst.json.gz")
+	e = FlattenFile(src, dst)
+	if e != nil {
+		t.Fatal(e)
+	}
+	js, err := NewJSONStreamer(dst)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer js.Close()
+	expected := []string{`{"a.b":12345678901234567890}`, `{"c.0":true}`}
+	for i := 0; ; i++ {
+		var raw json.RawMessage
+		e := js.Next(&raw)
+		if e == Done {
+			break
+		}
+		if e != nil {
+			t.Fatal(e)
+		}
+		if string(raw) != expected[i] {
+			t.Fatalf("expected %s, got %s", expected[i], raw)
+		}
+	}
+}
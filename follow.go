@@ -0,0 +1,114 @@
+// Copyright (c) 2015 AKUALAB INC., All rights reserved.
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ju
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"time"
+)
+
+// FollowJSON streams the json objects in a file that is being appended to, like "tail -f".
+// It reads the existing content and then polls the file for new objects until ctx is cancelled.
+// Only complete lines are decoded, so objects that are partially written are not emitted until
+// the rest of the object and its trailing newline are available. If the file is truncated,
+// reading starts again from the beginning. Gzipped files are not supported.
+//
+// Both channels are closed when ctx is cancelled or an error occurs. Errors are sent to the
+// error channel before closing it. Use WithPollInterval to change how often the file is polled.
+func FollowJSON(path string, ctx context.Context, opts ...Option) (<-chan json.RawMessage, <-chan error) {
+
+	o := newOptions(opts...)
+	out := make(chan json.RawMessage)
+	errCh := make(chan error, 1)
+	go func() {
+		defer close(errCh)
+		defer close(out)
+		err := follow(ctx, path, out, o)
+		if err != nil && !errors.Is(err, context.Canceled) && !errors.Is(err, context.DeadlineExceeded) {
+			errCh <- err
+		}
+	}()
+	return out, errCh
+}
+
+func follow(ctx context.Context, path string, out chan<- json.RawMessage, o *options) error {
+
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	var offset int64 // file offset of the start of buf
+	var buf []byte
+	chunk := make([]byte, 64*1024)
+	ticker := time.NewTicker(o.pollInterval)
+	defer ticker.Stop()
+	for {
+		// Read everything available.
+		for {
+			n, err := f.Read(chunk)
+			buf = append(buf, chunk[:n]...)
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				return err
+			}
+		}
+
+		// Decode the complete lines.
+		if end := bytes.LastIndexByte(buf, '\n'); end >= 0 {
+			dec := json.NewDecoder(bytes.NewReader(buf[:end+1]))
+			var consumed int64
+			for {
+				var raw json.RawMessage
+				err := dec.Decode(&raw)
+				if err == io.EOF || err == io.ErrUnexpectedEOF {
+					// Incomplete multi-line object, wait for more data.
+					break
+				}
+				if err != nil {
+					return err
+				}
+				consumed = dec.InputOffset()
+				select {
+				case out <- raw:
+				case <-ctx.Done():
+					return ctx.Err()
+				}
+			}
+			offset += consumed
+			buf = append(buf[:0], buf[consumed:]...)
+		}
+
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+
+		// Start over if the file was truncated.
+		fi, err := f.Stat()
+		if err != nil {
+			return err
+		}
+		if fi.Size() < offset+int64(len(buf)) {
+			o.logger.Info("file truncated, reading from the beginning", "path", path)
+			_, err = f.Seek(0, io.SeekStart)
+			if err != nil {
+				return err
+			}
+			offset = 0
+			buf = buf[:0]
+		}
+	}
+}
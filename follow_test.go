@@ -0,0 +1,69 @@
+// Copyright (c) 2015 AKUALAB INC., All rights reserved.
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ju
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestFollowJSON(t *testing.T) {
+
+	fn := filepath.Join(os.TempDir(), "follow", "follow.json")
+	e := os.MkdirAll(filepath.Dir(fn), 0777)
+	if e != nil {
+		t.Fatal(e)
+	}
+	e = os.WriteFile(fn, []byte(`{"N":0}`+"\n"+`{"N":1}`+"\n"), 0644)
+	if e != nil {
+		t.Fatal(e)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	objs, errs := FollowJSON(fn, ctx, WithPollInterval(10*time.Millisecond))
+
+	f, err := os.OpenFile(fn, os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	go func() {
+		// Write an object in pieces.
+		time.Sleep(50 * time.Millisecond)
+		f.WriteString(`{"N":`)
+		time.Sleep(50 * time.Millisecond)
+		f.WriteString(`2}`)
+		time.Sleep(50 * time.Millisecond)
+		f.WriteString("\n{\n\"N\": 3\n")
+		time.Sleep(50 * time.Millisecond)
+		f.WriteString("}\n")
+	}()
+
+	for i := 0; i < 4; i++ {
+		raw, ok := <-objs
+		if !ok {
+			t.Fatal(<-errs)
+		}
+		var o tt
+		e := DefaultCodec.Unmarshal(raw, &o)
+		if e != nil {
+			t.Fatal(e)
+		}
+		if o.N != i {
+			t.Fatalf("expected %d, got %d", i, o.N)
+		}
+	}
+	cancel()
+	for range objs {
+	}
+	if e := <-errs; e != nil {
+		t.Fatal(e)
+	}
+}
@@ -0,0 +1,127 @@
+// Copyright (c) 2015 AKUALAB INC., All rights reserved.
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ju
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"io/fs"
+	"path"
+	"strings"
+)
+
+// FileStreamerFS is like FileStreamer but reads from the file system fsys, for example an embed.FS.
+// Paths use forward slashes as described in io/fs. Single files, directories, and ".list" files are
+// supported; paths in a ".list" file are relative to the root of fsys. Archives are not supported.
+// Symbolic links found in a directory are skipped.
+func FileStreamerFS(fsys fs.FS, name string, ext ...string) (io.ReadCloser, error) {
+	return NewFileStreamerFS(fsys, name, ext)
+}
+
+// NewFileStreamerFS is like FileStreamerFS but also accepts options. WithRetry, WithSkipMissing,
+// and WithRejectSymlinks do not apply to a fs.FS.
+func NewFileStreamerFS(fsys fs.FS, name string, ext []string, opts ...Option) (io.ReadCloser, error) {
+	o := newOptions(opts...)
+	if o.err != nil {
+		return nil, o.err
+	}
+	if path.Ext(name) == ".zip" || isTar(name) {
+		return nil, fmt.Errorf("%s: archives are not supported by FileStreamerFS", name)
+	}
+	files, err := extractPathsFS(fsys, name, o, ext...)
+	if err != nil {
+		return nil, err
+	}
+	return newMultiSource(&fsSource{fsys: fsys, files: selectFiles(files, o), opts: o}, o), nil
+}
+
+// extractPathsFS is like extractPaths for a fs.FS.
+func extractPathsFS(fsys fs.FS, name string, o *options, ext ...string) ([]string, error) {
+	files := []string{}
+	allowed := allowedExt(ext...)
+	fi, err := fs.Stat(fsys, name)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case fi.IsDir():
+		err := fs.WalkDir(fsys, name, func(fn string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if d.IsDir() || d.Type()&fs.ModeSymlink != 0 {
+				return nil
+			}
+			if !o.irregular && !d.Type().IsRegular() {
+				return nil
+			}
+			if !fileNameRegexp.MatchString(path.Base(fn)) || !matchExt(path.Ext(fn), allowed) {
+				return nil
+			}
+			files = append(files, fn)
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	case path.Ext(name) == ".list":
+		f, err := fsys.Open(name)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		scanner := bufio.NewScanner(f)
+		for scanner.Scan() {
+			line := strings.TrimSpace(scanner.Text())
+			if line == "" {
+				continue
+			}
+			files = append(files, line)
+		}
+		if err := scanner.Err(); err != nil {
+			return nil, err
+		}
+	default:
+		files = append(files, name)
+	}
+	return files, nil
+}
+
+// fsSource reads files from a fs.FS.
+type fsSource struct {
+	fsys  fs.FS
+	files []string
+	idx   int
+	opts  *options
+}
+
+func (s *fsSource) next() (string, io.ReadCloser, error) {
+	if s.idx >= len(s.files) {
+		return "", nil, io.EOF
+	}
+	name := s.files[s.idx]
+	s.idx++
+	f, err := s.fsys.Open(name)
+	if err != nil {
+		return "", nil, fileError(name, err)
+	}
+	r, err := s.opts.decode(name, f)
+	if err != nil {
+		return "", nil, fileError(name, err)
+	}
+	return name, r, nil
+}
+
+func (s *fsSource) total() int {
+	return len(s.files)
+}
+
+func (s *fsSource) Close() error {
+	s.idx = 0
+	s.files = nil
+	return nil
+}
@@ -0,0 +1,99 @@
+// Copyright (c) 2015 AKUALAB INC., All rights reserved.
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ju
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"io/fs"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestFileStreamerFS(t *testing.T) {
+
+	var gz bytes.Buffer
+	zw := gzip.NewWriter(&gz)
+	zw.Write([]byte(`{"N":2}` + "\n"))
+	zw.Close()
+	fsys := fstest.MapFS{
+		"data/a.json":    {Data: []byte(`{"N":1}` + "\n")},
+		"data/b.json.gz": {Data: gz.Bytes()},
+		"data/c.txt":     {Data: []byte("not json")},
+		"data/.hidden":   {Data: []byte("x")},
+		"files.list":     {Data: []byte("data/a.json\n\ndata/b.json.gz\n")},
+	}
+
+	for _, name := range []string{"data", "files.list"} {
+		rc, err := FileStreamerFS(fsys, name, ".json")
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, err := io.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(data) != "{\"N\":1}\n{\"N\":2}\n" {
+			t.Fatalf("%s: unexpected data %q", name, data)
+		}
+	}
+	_, err := FileStreamerFS(fsys, "missing.json")
+	if err == nil {
+		t.Fatal("expected error for missing file")
+	}
+}
+
+func TestNewFileStreamerFS(t *testing.T) {
+
+	fsys := fstest.MapFS{
+		"data/a.json": {Data: []byte(`{"N":1}` + "\n")},
+		"data/b.json": {Data: []byte(`{"N":2}` + "\n")},
+		"data/c.json": {Data: []byte(`{"N":3}` + "\n")},
+		"data/p.json": {Data: []byte(`{"N":4}` + "\n"), Mode: fs.ModeNamedPipe},
+	}
+
+	var names []string
+	progress := func(done, total int, path string) {
+		names = append(names, path)
+	}
+	rc, err := NewFileStreamerFS(fsys, "data", nil, WithMaxFiles(2), WithProgress(progress))
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := io.ReadAll(rc)
+	rc.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "{\"N\":1}\n{\"N\":2}\n" {
+		t.Fatalf("unexpected data %q", data)
+	}
+	if strings.Join(names, ",") != "data/a.json,data/b.json," {
+		t.Fatalf("unexpected progress %q", names)
+	}
+
+	// Non-regular files are skipped by default.
+	for _, c := range []struct {
+		opts []Option
+		n    int
+	}{{nil, 3}, {[]Option{WithNonRegularFiles()}, 4}} {
+		rc, err := NewFileStreamerFS(fsys, "data", nil, c.opts...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, err := io.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if n := strings.Count(string(data), "\n"); n != c.n {
+			t.Fatalf("expected %d objects, got %d", c.n, n)
+		}
+	}
+}
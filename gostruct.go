@@ -0,0 +1,186 @@
+// Copyright (c) 2015 AKUALAB INC., All rights reserved.
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ju
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"go/token"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// GenerateStruct reads up to sampleN json objects from srcPath and returns the Go source of
+// a struct type named typeName that can decode them. Field names are exported camel case
+// versions of the json keys and each field has a json tag with the original key. Nested
+// objects are declared as separate struct types named after typeName and the field path.
+// Fields that are sometimes null are pointers; fields with values of different types are
+// interface{}. Keys that cannot be expressed in a struct tag are listed in a comment.
+// See InferSchema for the sampling and FileStreamer for srcPath and ext.
+func GenerateStruct(srcPath, typeName string, sampleN int, ext ...string) (string, error) {
+	if !token.IsIdentifier(typeName) {
+		return "", fmt.Errorf("invalid type name %q", typeName)
+	}
+	root, err := inferSample(srcPath, sampleN, ext)
+	if err != nil {
+		return "", err
+	}
+	g := &structGen{names: map[string]bool{typeName: true}}
+	g.queue = append(g.queue, structDef{typeName, root})
+	for i := 0; i < len(g.queue); i++ {
+		g.writeStruct(g.queue[i])
+	}
+	src, err := format.Source(g.buf.Bytes())
+	if err != nil {
+		return "", err
+	}
+	return string(src), nil
+}
+
+type structDef struct {
+	name string
+	node *inferNode
+}
+
+// structGen generates struct declarations. Nested structs are queued and declared
+// after the struct that uses them.
+type structGen struct {
+	buf   bytes.Buffer
+	names map[string]bool // type names in use
+	queue []structDef
+}
+
+func (g *structGen) writeStruct(d structDef) {
+	if g.buf.Len() > 0 {
+		g.buf.WriteString("\n")
+	}
+	fmt.Fprintf(&g.buf, "type %s struct {\n", d.name)
+	used := map[string]bool{}
+	var skipped []string
+	for _, k := range d.node.keys {
+		if strings.ContainsAny(k, "\"`,\\") || k == "-" {
+			skipped = append(skipped, strconv.Quote(k))
+			continue
+		}
+		name := uniqueName(goName(k), used)
+		typ := g.goType(d.name+name, d.node.fields[k])
+		fmt.Fprintf(&g.buf, "\t%s %s `json:\"%s\"`\n", name, typ, k)
+	}
+	if len(skipped) > 0 {
+		fmt.Fprintf(&g.buf, "\t// Keys not represented: %s.\n", strings.Join(skipped, ", "))
+	}
+	g.buf.WriteString("}\n")
+}
+
+// goType returns the Go type for the values of node n. name is the type name to use
+// if n is an object.
+func (g *structGen) goType(name string, n *inferNode) string {
+	types := map[string]bool{}
+	for t := range n.types {
+		if t == "integer" && n.types["number"] {
+			continue
+		}
+		types[t] = true
+	}
+	nullable := types["null"]
+	delete(types, "null")
+	if len(types) != 1 {
+		return "interface{}"
+	}
+	var typ string
+	for t := range types {
+		typ = t
+	}
+	switch typ {
+	case "string":
+		typ = "string"
+	case "integer":
+		typ = "int64"
+	case "number":
+		typ = "float64"
+	case "boolean":
+		typ = "bool"
+	case "array":
+		elem := "interface{}"
+		if n.elem != nil && len(n.elem.types) > 0 {
+			elem = g.goType(name, n.elem)
+		}
+		return "[]" + elem
+	case "object":
+		typ = uniqueName(name, g.names)
+		g.queue = append(g.queue, structDef{typ, n})
+	}
+	if nullable {
+		return "*" + typ
+	}
+	return typ
+}
+
+// commonInitialisms are written in upper case in field names, as in golint.
+var commonInitialisms = map[string]bool{
+	"API": true, "ASCII": true, "CPU": true, "CSS": true, "DNS": true, "EOF": true,
+	"GUID": true, "HTML": true, "HTTP": true, "HTTPS": true, "ID": true, "IP": true,
+	"JSON": true, "SQL": true, "TCP": true, "TLS": true, "TTL": true, "UI": true,
+	"UID": true, "URI": true, "URL": true, "UTF8": true, "UUID": true, "XML": true,
+}
+
+// goName converts a json key into an exported Go identifier, for example "user_id" to "UserID".
+func goName(key string) string {
+	var words []string
+	var word []rune
+	flush := func() {
+		if len(word) > 0 {
+			words = append(words, string(word))
+			word = nil
+		}
+	}
+	var prev rune
+	for _, r := range key {
+		switch {
+		case !unicode.IsLetter(r) && !unicode.IsDigit(r):
+			flush()
+		case unicode.IsUpper(r) && unicode.IsLower(prev):
+			flush()
+			word = append(word, r)
+		default:
+			word = append(word, r)
+		}
+		prev = r
+	}
+	flush()
+	var sb strings.Builder
+	for _, w := range words {
+		if u := strings.ToUpper(w); commonInitialisms[u] {
+			sb.WriteString(u)
+			continue
+		}
+		rs := []rune(w)
+		rs[0] = unicode.ToUpper(rs[0])
+		sb.WriteString(string(rs))
+	}
+	name := sb.String()
+	if name == "" {
+		return "Field"
+	}
+	if r := []rune(name)[0]; !unicode.IsUpper(r) {
+		// Starts with a digit or a letter without case.
+		name = "X" + name
+	}
+	return name
+}
+
+// uniqueName returns name, or name followed by a number if it is already used,
+// and marks the result as used.
+func uniqueName(name string, used map[string]bool) string {
+	n := name
+	for i := 2; used[n]; i++ {
+		n = name + strconv.Itoa(i)
+	}
+	used[n] = true
+	return n
+}
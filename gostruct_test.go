@@ -0,0 +1,62 @@
+// Copyright (c) 2015 AKUALAB INC., All rights reserved.
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ju
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestGenerateStruct(t *testing.T) {
+
+	fn := filepath.Join(os.TempDir(), "gostruct", "data.json")
+	e := os.MkdirAll(filepath.Dir(fn), 0777)
+	if e != nil {
+		t.Fatal(e)
+	}
+	data := inferData + `{"user_id": 5, "userID": 6, "2fa": false, "a,b": 1, "geo": {"lat": 0, "lon": 0, "alt": 1}}
+`
+	e = os.WriteFile(fn, []byte(data), 0644)
+	if e != nil {
+		t.Fatal(e)
+	}
+	src, err := GenerateStruct(fn, "Record", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "type Record struct {\n" +
+		"\tID      interface{}   `json:\"id\"`\n" +
+		"\tName    *string       `json:\"name\"`\n" +
+		"\tTags    []string      `json:\"tags\"`\n" +
+		"\tGeo     *RecordGeo    `json:\"geo\"`\n" +
+		"\tOk      bool          `json:\"ok\"`\n" +
+		"\tItems   []RecordItems `json:\"items\"`\n" +
+		"\tExtra   string        `json:\"extra\"`\n" +
+		"\tUserID  int64         `json:\"user_id\"`\n" +
+		"\tUserID2 int64         `json:\"userID\"`\n" +
+		"\tX2fa    bool          `json:\"2fa\"`\n" +
+		"\t// Keys not represented: \"a,b\".\n" +
+		"}\n\n" +
+		"type RecordGeo struct {\n" +
+		"\tLat float64 `json:\"lat\"`\n" +
+		"\tLon int64   `json:\"lon\"`\n" +
+		"\tAlt int64   `json:\"alt\"`\n" +
+		"}\n\n" +
+		"type RecordItems struct {\n" +
+		"\tSku   string  `json:\"sku\"`\n" +
+		"\tQty   int64   `json:\"qty\"`\n" +
+		"\tPrice float64 `json:\"price\"`\n" +
+		"}\n"
+	if src != expected {
+		t.Fatalf("expected:\n%s\ngot:\n%s", expected, src)
+	}
+
+	_, err = GenerateStruct(fn, "not valid", 0)
+	if err == nil {
+		t.Fatal("expected error for invalid type name")
+	}
+}
@@ -0,0 +1,100 @@
+// Copyright (c) 2015 AKUALAB INC., All rights reserved.
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ju
+
+import (
+	"encoding/json"
+)
+
+// GroupBy reads json objects from srcPath, groups them by the key computed with keyFn, and
+// writes to dstPath the result of agg for each group. Groups are aggregated in the order
+// in which their keys are first seen. If agg returns nil, nothing is written for the group.
+// All the objects are kept in memory; use GroupBySorted for large inputs that are sorted by key.
+// See FileStreamer for srcPath and NewWriter for dstPath.
+func GroupBy(srcPath, dstPath string, keyFn func(json.RawMessage) (string, error),
+	agg func(key string, members []json.RawMessage) (json.RawMessage, error)) error {
+
+	var keys []string
+	groups := map[string][]json.RawMessage{}
+	err := forEachRaw(srcPath, nil, func(raw json.RawMessage) error {
+		key, err := keyFn(raw)
+		if err != nil {
+			return err
+		}
+		if _, ok := groups[key]; !ok {
+			keys = append(keys, key)
+		}
+		groups[key] = append(groups[key], raw)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	w, err := NewWriter(dstPath)
+	if err != nil {
+		return err
+	}
+	for _, key := range keys {
+		err = writeGroup(w, key, groups[key], agg)
+		if err != nil {
+			w.Close()
+			return err
+		}
+		delete(groups, key)
+	}
+	return w.Close()
+}
+
+// GroupBySorted is like GroupBy but requires the objects in srcPath to be sorted by key
+// so that only the objects of the current group are kept in memory.
+// An error is returned if the input is not sorted.
+func GroupBySorted(srcPath, dstPath string, keyFn func(json.RawMessage) (string, error),
+	agg func(key string, members []json.RawMessage) (json.RawMessage, error)) error {
+
+	src, err := newSortedSource(srcPath, []string{".json"}, keyFn)
+	if err != nil {
+		return err
+	}
+	defer src.close()
+	w, err := NewWriter(dstPath)
+	if err != nil {
+		return err
+	}
+	err = func() error {
+		for !src.done {
+			key := src.key
+			var members []json.RawMessage
+			for !src.done && src.key == key {
+				members = append(members, src.raw)
+				err := src.next()
+				if err != nil {
+					return err
+				}
+			}
+			err := writeGroup(w, key, members, agg)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}()
+	if err != nil {
+		w.Close()
+		return err
+	}
+	return w.Close()
+}
+
+func writeGroup(w *Writer, key string, members []json.RawMessage,
+	agg func(key string, members []json.RawMessage) (json.RawMessage, error)) error {
+
+	res, err := agg(key, members)
+	if err != nil || res == nil {
+		return err
+	}
+	return w.Write(res)
+}
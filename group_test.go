@@ -0,0 +1,93 @@
+// Copyright (c) 2015 AKUALAB INC., All rights reserved.
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ju
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestGroupBy(t *testing.T) {
+
+	dir := filepath.Join(os.TempDir(), "groupby")
+	os.RemoveAll(dir)
+	src := filepath.Join(dir, "src.json")
+	sorted := filepath.Join(dir, "sorted.json")
+	e := WriteAll(src, []tt{{Name: "b", N: 1}, {Name: "a", N: 2}, {Name: "b", N: 3}, {Name: "c", N: 4}})
+	if e != nil {
+		t.Fatal(e)
+	}
+	e = WriteAll(sorted, []tt{{Name: "a", N: 2}, {Name: "b", N: 1}, {Name: "b", N: 3}, {Name: "c", N: 4}})
+	if e != nil {
+		t.Fatal(e)
+	}
+	keyFn := func(raw json.RawMessage) (string, error) {
+		var o tt
+		err := json.Unmarshal(raw, &o)
+		return o.Name, err
+	}
+	agg := func(key string, members []json.RawMessage) (json.RawMessage, error) {
+		if key == "c" {
+			return nil, nil
+		}
+		sum := 0
+		for _, m := range members {
+			var o tt
+			err := json.Unmarshal(m, &o)
+			if err != nil {
+				return nil, err
+			}
+			sum += o.N
+		}
+		return json.Marshal(tt{Name: key, N: sum})
+	}
+	read := func(fn string) string {
+		var got []string
+		js, err := NewJSONStreamer(fn)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer js.Close()
+		for {
+			var o tt
+			e := js.Next(&o)
+			if e == Done {
+				break
+			}
+			if e != nil {
+				t.Fatal(e)
+			}
+			got = append(got, o.Name+string(rune('0'+o.N)))
+		}
+		return strings.Join(got, ",")
+	}
+
+	dst := filepath.Join(dir, "out", "groups.json")
+	e = GroupBy(src, dst, keyFn, agg)
+	if e != nil {
+		t.Fatal(e)
+	}
+	if got := read(dst); got != "b4,a2" {
+		t.Fatalf("expected b4,a2, got %s", got)
+	}
+
+	dst = filepath.Join(dir, "out", "sorted-groups.json")
+	e = GroupBySorted(sorted, dst, keyFn, agg)
+	if e != nil {
+		t.Fatal(e)
+	}
+	if got := read(dst); got != "a2,b4" {
+		t.Fatalf("expected a2,b4, got %s", got)
+	}
+
+	e = GroupBySorted(src, dst, keyFn, agg)
+	if e == nil || !strings.Contains(e.Error(), "not sorted") {
+		t.Fatalf("expected not sorted error, got %v", e)
+	}
+}
@@ -0,0 +1,113 @@
+// Copyright (c) 2015 AKUALAB INC., All rights reserved.
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ju
+
+import (
+	"container/heap"
+	"encoding/json"
+	"fmt"
+	"sort"
+)
+
+// Histogram counts the json objects in srcPath by the key computed with keyFn.
+// All the keys are kept in memory; use HistogramTopK for keys with very high cardinality.
+// See FileStreamer for srcPath and ext.
+func Histogram(srcPath string, keyFn func(json.RawMessage) (string, error), ext ...string) (map[string]int64, error) {
+	counts := map[string]int64{}
+	err := forEachRaw(srcPath, ext, func(raw json.RawMessage) error {
+		key, err := keyFn(raw)
+		if err != nil {
+			return err
+		}
+		counts[key]++
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return counts, nil
+}
+
+// KeyCount is the estimated count of a key returned by HistogramTopK.
+type KeyCount struct {
+	Key string
+	// Count is an upper bound of the number of objects with the key.
+	Count int64
+	// Err is the maximum overestimation; the true count is at least Count - Err.
+	Err int64
+}
+
+// HistogramTopK estimates the k most frequent keys in srcPath using at most k counters
+// (the Space-Saving algorithm). Keys whose true count is larger than n/k, where n is the number
+// of objects, are guaranteed to be in the result. Results are sorted by count in descending order.
+// See Histogram.
+func HistogramTopK(srcPath string, keyFn func(json.RawMessage) (string, error), k int, ext ...string) ([]KeyCount, error) {
+	if k < 1 {
+		return nil, fmt.Errorf("invalid k: %d", k)
+	}
+	h := &counterHeap{index: map[string]int{}}
+	err := forEachRaw(srcPath, ext, func(raw json.RawMessage) error {
+		key, err := keyFn(raw)
+		if err != nil {
+			return err
+		}
+		if i, ok := h.index[key]; ok {
+			h.items[i].Count++
+			heap.Fix(h, i)
+			return nil
+		}
+		if len(h.items) < k {
+			heap.Push(h, KeyCount{Key: key, Count: 1})
+			return nil
+		}
+		// Replace the key with the smallest count.
+		min := h.items[0]
+		delete(h.index, min.Key)
+		h.items[0] = KeyCount{Key: key, Count: min.Count + 1, Err: min.Count}
+		h.index[key] = 0
+		heap.Fix(h, 0)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	res := h.items
+	sort.Slice(res, func(i, j int) bool {
+		if res[i].Count != res[j].Count {
+			return res[i].Count > res[j].Count
+		}
+		return res[i].Key < res[j].Key
+	})
+	return res, nil
+}
+
+// counterHeap is a min-heap of counters by count.
+type counterHeap struct {
+	items []KeyCount
+	index map[string]int
+}
+
+func (h *counterHeap) Len() int           { return len(h.items) }
+func (h *counterHeap) Less(i, j int) bool { return h.items[i].Count < h.items[j].Count }
+
+func (h *counterHeap) Swap(i, j int) {
+	h.items[i], h.items[j] = h.items[j], h.items[i]
+	h.index[h.items[i].Key] = i
+	h.index[h.items[j].Key] = j
+}
+
+func (h *counterHeap) Push(x interface{}) {
+	kc := x.(KeyCount)
+	h.index[kc.Key] = len(h.items)
+	h.items = append(h.items, kc)
+}
+
+func (h *counterHeap) Pop() interface{} {
+	kc := h.items[len(h.items)-1]
+	h.items = h.items[:len(h.items)-1]
+	delete(h.index, kc.Key)
+	return kc
+}
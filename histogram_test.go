@@ -0,0 +1,58 @@
+// Copyright (c) 2015 AKUALAB INC., All rights reserved.
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ju
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestHistogram(t *testing.T) {
+
+	fn := filepath.Join(os.TempDir(), "histogram", "h.json.gz")
+	var objs []tt
+	// Key "a" 300 times, "b" 200 times, and 100 keys once.
+	for i := 0; i < 300; i++ {
+		objs = append(objs, tt{Name: "a"})
+		if i < 200 {
+			objs = append(objs, tt{Name: "b"})
+		}
+		if i < 100 {
+			objs = append(objs, tt{Name: fmt.Sprintf("k%d", i)})
+		}
+	}
+	e := WriteAll(fn, objs)
+	if e != nil {
+		t.Fatal(e)
+	}
+
+	counts, err := Histogram(fn, nameKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(counts) != 102 || counts["a"] != 300 || counts["b"] != 200 || counts["k7"] != 1 {
+		t.Fatalf("unexpected counts: %d keys, a=%d b=%d", len(counts), counts["a"], counts["b"])
+	}
+
+	top, err := HistogramTopK(fn, nameKey, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(top) != 10 || top[0].Key != "a" || top[1].Key != "b" {
+		t.Fatalf("unexpected top keys: %v", top)
+	}
+	for _, kc := range top[:2] {
+		if kc.Count-kc.Err > counts[kc.Key] || kc.Count < counts[kc.Key] {
+			t.Fatalf("count %v does not bound true count %d", kc, counts[kc.Key])
+		}
+	}
+	_, err = HistogramTopK(fn, nameKey, 0)
+	if err == nil {
+		t.Fatal("expected error for invalid k")
+	}
+}
@@ -0,0 +1,99 @@
+// Copyright (c) 2015 AKUALAB INC., All rights reserved.
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ju
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// BuildIndex returns the byte offset of the first byte of each json object in the file path,
+// after any whitespace that precedes it.
+// Use the index with RecordAt for random access. Only uncompressed files can be indexed.
+func BuildIndex(path string) ([]int64, error) {
+	if filepath.Ext(path) == ".gz" {
+		return nil, fmt.Errorf("cannot index compressed file %s", path)
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	dec := json.NewDecoder(bufio.NewReader(f))
+	var offsets []int64
+	for {
+		var raw json.RawMessage
+		err := dec.Decode(&raw)
+		if err == io.EOF {
+			return offsets, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s: object %d: %w", path, len(offsets), err)
+		}
+		// The raw value has no surrounding whitespace and the decoder stops at its end,
+		// which gives the offset of the first byte of the value.
+		offsets = append(offsets, dec.InputOffset()-int64(len(raw)))
+	}
+}
+
+// WithIndexSidecar makes a Writer record the byte offset of each object it writes and save the
+// offsets to the file path when the Writer is closed, as a json array. Load the offsets with
+// ReadIndex and use them with RecordAt for random access. Only plain files can be indexed, not
+// gzipped, encrypted, or base64 encoded files.
+func WithIndexSidecar(path string) Option {
+	return func(o *options) {
+		o.indexPath = path
+	}
+}
+
+// ReadIndex reads the offsets saved by a Writer created with WithIndexSidecar.
+func ReadIndex(path string) ([]int64, error) {
+	var offsets []int64
+	err := ReadJSONFile(path, &offsets)
+	if err != nil {
+		return nil, err
+	}
+	return offsets, nil
+}
+
+// indexWriter counts the bytes written to a file.
+type indexWriter struct {
+	w       io.Writer
+	path    string  // sidecar file
+	n       int64   // bytes written
+	offsets []int64 // offset of each object
+}
+
+func (iw *indexWriter) Write(p []byte) (int, error) {
+	n, err := iw.w.Write(p)
+	iw.n += int64(n)
+	return n, err
+}
+
+// RecordAt decodes the kth json object in the file path into dst using an index
+// created by BuildIndex or read with ReadIndex. The file must not change after the index is built.
+func RecordAt(path string, offsets []int64, k int, dst interface{}) error {
+	if k < 0 || k >= len(offsets) {
+		return fmt.Errorf("record %d out of range [0,%d)", k, len(offsets))
+	}
+	if filepath.Ext(path) == ".gz" {
+		return fmt.Errorf("cannot seek in compressed file %s", path)
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = f.Seek(offsets[k], io.SeekStart)
+	if err != nil {
+		return err
+	}
+	return DefaultCodec.NewDecoder(f).Decode(dst)
+}
@@ -0,0 +1,155 @@
+// Copyright (c) 2015 AKUALAB INC., All rights reserved.
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ju
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestIndex(t *testing.T) {
+
+	dir := filepath.Join(os.TempDir(), "index")
+	os.RemoveAll(dir)
+	var ref []tt
+	for i := 0; i < 100; i++ {
+		ref = append(ref, tt{Name: "index", N: i, Words: []string{"a"}})
+	}
+	fn := filepath.Join(dir, "i.json")
+	e := WriteAll(fn, ref)
+	if e != nil {
+		t.Fatal(e)
+	}
+	offsets, err := BuildIndex(fn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(offsets) != len(ref) || offsets[0] != 0 {
+		t.Fatalf("unexpected index: %v", offsets)
+	}
+	for _, k := range []int{99, 0, 42} {
+		var o tt
+		e := RecordAt(fn, offsets, k, &o)
+		if e != nil {
+			t.Fatal(e)
+		}
+		if !ref[k].equal(o) {
+			t.Fatalf("expected %v, got %v", ref[k], o)
+		}
+	}
+	var o tt
+	e = RecordAt(fn, offsets, 100, &o)
+	if e == nil {
+		t.Fatal("expected out of range error")
+	}
+
+	gz := filepath.Join(dir, "i.json.gz")
+	e = WriteAll(gz, ref)
+	if e != nil {
+		t.Fatal(e)
+	}
+	_, err = BuildIndex(gz)
+	if err == nil {
+		t.Fatal("expected error for compressed file")
+	}
+}
+
+func TestIndexSidecar(t *testing.T) {
+
+	dir := filepath.Join(os.TempDir(), "index-sidecar")
+	os.RemoveAll(dir)
+	fn := filepath.Join(dir, "s.json")
+	idx := filepath.Join(dir, "s.idx")
+	w, err := NewWriter(fn, WithIndexSidecar(idx))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ref []tt
+	for i := 0; i < 50; i++ {
+		x := tt{Name: "sidecar", N: i, Words: make([]string, i%4)}
+		ref = append(ref, x)
+		e := w.Write(x)
+		if e != nil {
+			t.Fatal(e)
+		}
+	}
+	e := w.Close()
+	if e != nil {
+		t.Fatal(e)
+	}
+
+	offsets, err := ReadIndex(idx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(offsets) != len(ref) {
+		t.Fatalf("expected %d offsets, got %d", len(ref), len(offsets))
+	}
+	for _, k := range []int{49, 0, 17} {
+		var o tt
+		e := RecordAt(fn, offsets, k, &o)
+		if e != nil {
+			t.Fatal(e)
+		}
+		if !ref[k].equal(o) {
+			t.Fatalf("expected %v, got %v", ref[k], o)
+		}
+	}
+
+	_, err = NewWriter(fn+".gz", WithIndexSidecar(idx))
+	if err == nil {
+		t.Fatal("expected error for gzipped file")
+	}
+}
+
+func TestBuildIndexMatchesSidecar(t *testing.T) {
+
+	dir := filepath.Join(os.TempDir(), "index-match")
+	os.RemoveAll(dir)
+	fn := filepath.Join(dir, "m.json")
+	idx := filepath.Join(dir, "m.idx")
+	w, err := NewWriter(fn, WithIndexSidecar(idx))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, v := range []interface{}{tt{N: 1}, 22, "x", []int{3}, tt{Name: "b"}} {
+		e := w.Write(v)
+		if e != nil {
+			t.Fatal(e)
+		}
+	}
+	e := w.Close()
+	if e != nil {
+		t.Fatal(e)
+	}
+	sidecar, err := ReadIndex(idx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	built, err := BuildIndex(fn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fmt.Sprint(built) != fmt.Sprint(sidecar) {
+		t.Fatalf("expected %v, got %v", sidecar, built)
+	}
+
+	// Offsets point to the first byte of each value.
+	ws := filepath.Join(dir, "ws.json")
+	e = os.WriteFile(ws, []byte("  {\"N\":1}\n\n\t{\"N\":2} 3\n"), 0644)
+	if e != nil {
+		t.Fatal(e)
+	}
+	built, err = BuildIndex(ws)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fmt.Sprint(built) != "[2 12 20]" {
+		t.Fatalf("expected [2 12 20], got %v", built)
+	}
+}
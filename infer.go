@@ -0,0 +1,133 @@
+// Copyright (c) 2015 AKUALAB INC., All rights reserved.
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ju
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// InferSchema reads up to sampleN json objects from srcPath and returns the types observed
+// for each field. The keys are the dotted paths to the fields, for example "a.b" for the field
+// b of the object in field a. The elements of an array at path "a" are described by path "a[]".
+// The types are the json types "object", "array", "string", "integer", "number", "boolean",
+// and "null". When a field has values of several types, the type is the union of the types
+// sorted and separated by "|", for example "null|string". Integers and numbers are merged into
+// "number". If sampleN is zero or negative, all the objects are read.
+// See FileStreamer for srcPath and ext.
+func InferSchema(srcPath string, sampleN int, ext ...string) (map[string]string, error) {
+	root, err := inferSample(srcPath, sampleN, ext)
+	if err != nil {
+		return nil, err
+	}
+	schema := map[string]string{}
+	root.walk("", schema)
+	return schema, nil
+}
+
+// inferNode holds the types observed at a path.
+type inferNode struct {
+	types  map[string]bool
+	keys   []string // field names in the order they were first seen
+	fields map[string]*inferNode
+	elem   *inferNode
+}
+
+func newInferNode() *inferNode {
+	return &inferNode{types: map[string]bool{}, fields: map[string]*inferNode{}}
+}
+
+// inferSample reads the objects in srcPath and returns the node of the top level objects.
+func inferSample(srcPath string, sampleN int, ext []string) (*inferNode, error) {
+	if sampleN < 0 {
+		sampleN = 0
+	}
+	js, err := newJSONStreamer(srcPath, ext, newOptions(WithLimit(sampleN)))
+	if err != nil {
+		return nil, err
+	}
+	defer js.Close()
+	root := newInferNode()
+	for {
+		var raw json.RawMessage
+		err := js.Next(&raw)
+		if err == Done {
+			return root, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		v, err := decodeOrdered(raw)
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := v.(*OrderedMap); !ok {
+			return nil, fmt.Errorf("cannot infer schema of %s, not an object", jsonType(v))
+		}
+		root.add(v)
+	}
+}
+
+// add records the type of v and of its fields or elements.
+func (n *inferNode) add(v interface{}) {
+	switch t := v.(type) {
+	case *OrderedMap:
+		n.types["object"] = true
+		for _, k := range t.Keys() {
+			f, ok := n.fields[k]
+			if !ok {
+				f = newInferNode()
+				n.fields[k] = f
+				n.keys = append(n.keys, k)
+			}
+			x, _ := t.Get(k)
+			f.add(x)
+		}
+	case []interface{}:
+		n.types["array"] = true
+		if n.elem == nil {
+			n.elem = newInferNode()
+		}
+		for _, x := range t {
+			n.elem.add(x)
+		}
+	default:
+		n.types[jsonType(v)] = true
+	}
+}
+
+// typ returns the union of the observed types.
+func (n *inferNode) typ() string {
+	types := make([]string, 0, len(n.types))
+	for t := range n.types {
+		if t == "integer" && n.types["number"] {
+			continue
+		}
+		types = append(types, t)
+	}
+	sort.Strings(types)
+	return strings.Join(types, "|")
+}
+
+// walk adds the types of the fields and elements of n to schema.
+func (n *inferNode) walk(path string, schema map[string]string) {
+	for _, k := range n.keys {
+		p := k
+		if path != "" {
+			p = path + "." + k
+		}
+		f := n.fields[k]
+		schema[p] = f.typ()
+		f.walk(p, schema)
+	}
+	if n.elem != nil && len(n.elem.types) > 0 {
+		p := path + "[]"
+		schema[p] = n.elem.typ()
+		n.elem.walk(p, schema)
+	}
+}
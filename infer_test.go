@@ -0,0 +1,71 @@
+// Copyright (c) 2015 AKUALAB INC., All rights reserved.
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ju
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+const inferData = `{"id": 1, "name": "a", "tags": ["x"], "geo": {"lat": 1.5, "lon": 2}}
+{"id": 2, "name": null, "tags": [], "geo": {"lat": 3, "lon": 4}, "ok": true}
+{"id": "3", "items": [{"sku": "s", "qty": 1}, {"sku": "t", "price": 2.5}], "geo": null}
+{"id": 4, "extra": "not sampled"}
+`
+
+func TestInferSchema(t *testing.T) {
+
+	fn := filepath.Join(os.TempDir(), "infer", "data.json")
+	e := os.MkdirAll(filepath.Dir(fn), 0777)
+	if e != nil {
+		t.Fatal(e)
+	}
+	e = os.WriteFile(fn, []byte(inferData), 0644)
+	if e != nil {
+		t.Fatal(e)
+	}
+	schema, err := InferSchema(fn, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string]string{
+		"id":            "integer|string",
+		"name":          "null|string",
+		"tags":          "array",
+		"tags[]":        "string",
+		"geo":           "null|object",
+		"geo.lat":       "number",
+		"geo.lon":       "integer",
+		"ok":            "boolean",
+		"items":         "array",
+		"items[]":       "object",
+		"items[].sku":   "string",
+		"items[].qty":   "integer",
+		"items[].price": "number",
+	}
+	if !reflect.DeepEqual(schema, expected) {
+		t.Fatalf("expected %v, got %v", expected, schema)
+	}
+
+	schema, err = InferSchema(fn, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if schema["extra"] != "string" {
+		t.Fatalf("expected all objects to be read, got %v", schema)
+	}
+
+	e = os.WriteFile(fn, []byte("[1]"), 0644)
+	if e != nil {
+		t.Fatal(e)
+	}
+	_, err = InferSchema(fn, 0)
+	if err == nil {
+		t.Fatal("expected error for array record")
+	}
+}
@@ -0,0 +1,180 @@
+// Copyright (c) 2015 AKUALAB INC., All rights reserved.
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ju
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// JoinType selects which unmatched objects are emitted by Join.
+type JoinType int
+
+const (
+	// InnerJoin emits only keys found in both sources.
+	InnerJoin JoinType = iota
+	// LeftJoin also emits keys found only in the left source.
+	LeftJoin
+	// RightJoin also emits keys found only in the right source.
+	RightJoin
+	// OuterJoin emits all keys.
+	OuterJoin
+)
+
+// WithJoin sets the join type used by Join. (Default InnerJoin.)
+func WithJoin(t JoinType) Option {
+	return func(o *options) {
+		o.join = t
+	}
+}
+
+// Join merges two sources of json objects sorted by key in ascending order and writes the
+// result of combine to dstPath. Keys are computed with keyFn and compared as strings.
+// For each key found in both sources, combine is called for each pair of left and right objects.
+// Depending on the join type (see WithJoin), combine is also called for unmatched objects with
+// nil for the missing side. If combine returns nil, nothing is written.
+// Only the objects of the current key are kept in memory. An error is returned if a source is not sorted.
+// See FileStreamer for leftPath, rightPath, and ext, and NewWriter for dstPath.
+func Join(leftPath, rightPath, dstPath string, keyFn func(json.RawMessage) (string, error),
+	combine func(l, r json.RawMessage) (json.RawMessage, error), ext []string, opts ...Option) error {
+
+	o := newOptions(opts...)
+	if o.err != nil {
+		return o.err
+	}
+	left, err := newSortedSource(leftPath, ext, keyFn)
+	if err != nil {
+		return err
+	}
+	defer left.close()
+	right, err := newSortedSource(rightPath, ext, keyFn)
+	if err != nil {
+		return err
+	}
+	defer right.close()
+
+	w, err := NewWriter(dstPath, opts...)
+	if err != nil {
+		return err
+	}
+	emit := func(l, r json.RawMessage) error {
+		res, err := combine(l, r)
+		if err != nil || res == nil {
+			return err
+		}
+		return w.Write(res)
+	}
+	keepLeft := o.join == LeftJoin || o.join == OuterJoin
+	keepRight := o.join == RightJoin || o.join == OuterJoin
+
+	err = func() error {
+		for !left.done || !right.done {
+			switch {
+			case right.done || (!left.done && left.key < right.key):
+				if keepLeft {
+					err := emit(left.raw, nil)
+					if err != nil {
+						return err
+					}
+				}
+				err := left.next()
+				if err != nil {
+					return err
+				}
+			case left.done || right.key < left.key:
+				if keepRight {
+					err := emit(nil, right.raw)
+					if err != nil {
+						return err
+					}
+				}
+				err := right.next()
+				if err != nil {
+					return err
+				}
+			default:
+				key := left.key
+				var group []json.RawMessage
+				for !right.done && right.key == key {
+					group = append(group, right.raw)
+					err := right.next()
+					if err != nil {
+						return err
+					}
+				}
+				for !left.done && left.key == key {
+					for _, r := range group {
+						err := emit(left.raw, r)
+						if err != nil {
+							return err
+						}
+					}
+					err := left.next()
+					if err != nil {
+						return err
+					}
+				}
+			}
+		}
+		return nil
+	}()
+	if err != nil {
+		w.Close()
+		return err
+	}
+	return w.Close()
+}
+
+// sortedSource reads objects and checks that their keys are sorted.
+type sortedSource struct {
+	path  string
+	js    *JSONStreamer
+	keyFn func(json.RawMessage) (string, error)
+	raw   json.RawMessage
+	key   string
+	done  bool
+}
+
+func newSortedSource(path string, ext []string, keyFn func(json.RawMessage) (string, error)) (*sortedSource, error) {
+	js, err := newJSONStreamer(path, ext, newOptions())
+	if err != nil {
+		return nil, err
+	}
+	s := &sortedSource{path: path, js: js, keyFn: keyFn}
+	err = s.next()
+	if err != nil {
+		js.Close()
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *sortedSource) next() error {
+	var raw json.RawMessage
+	err := s.js.Next(&raw)
+	if err == Done {
+		s.done = true
+		s.raw = nil
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	key, err := s.keyFn(raw)
+	if err != nil {
+		return err
+	}
+	if s.raw != nil && key < s.key {
+		return fmt.Errorf("%s is not sorted: key %q after %q", s.path, key, s.key)
+	}
+	s.raw = raw
+	s.key = key
+	return nil
+}
+
+func (s *sortedSource) close() error {
+	return s.js.Close()
+}
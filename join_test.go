@@ -0,0 +1,91 @@
+// Copyright (c) 2015 AKUALAB INC., All rights reserved.
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ju
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestJoin(t *testing.T) {
+
+	dir := filepath.Join(os.TempDir(), "join")
+	os.RemoveAll(dir)
+	left := filepath.Join(dir, "left.json")
+	right := filepath.Join(dir, "right")
+	e := WriteAll(left, []tt{{Name: "a", N: 1}, {Name: "b", N: 2}, {Name: "b", N: 3}, {Name: "d", N: 4}})
+	if e != nil {
+		t.Fatal(e)
+	}
+	// The right side is a directory of ndjson files; other files are ignored.
+	e = WriteAll(filepath.Join(right, "r.ndjson"), []tt{{Name: "b", N: 10}, {Name: "b", N: 20}, {Name: "c", N: 30}, {Name: "d", N: 40}})
+	if e != nil {
+		t.Fatal(e)
+	}
+	e = WriteAll(filepath.Join(right, "skip.json"), []tt{{Name: "z", N: 50}})
+	if e != nil {
+		t.Fatal(e)
+	}
+	ext := []string{".ndjson"}
+	keyFn := func(raw json.RawMessage) (string, error) {
+		var o tt
+		err := json.Unmarshal(raw, &o)
+		return o.Name, err
+	}
+	combine := func(l, r json.RawMessage) (json.RawMessage, error) {
+		var lo, ro tt
+		if l != nil {
+			json.Unmarshal(l, &lo)
+		}
+		if r != nil {
+			json.Unmarshal(r, &ro)
+		}
+		return json.Marshal(fmt.Sprintf("%d-%d", lo.N, ro.N))
+	}
+
+	cases := []struct {
+		jt       JoinType
+		expected string
+	}{
+		{InnerJoin, "2-10,2-20,3-10,3-20,4-40"},
+		{LeftJoin, "1-0,2-10,2-20,3-10,3-20,4-40"},
+		{RightJoin, "2-10,2-20,3-10,3-20,0-30,4-40"},
+		{OuterJoin, "1-0,2-10,2-20,3-10,3-20,0-30,4-40"},
+	}
+	for _, c := range cases {
+		dst := filepath.Join(dir, "out", fmt.Sprintf("join-%d.json", c.jt))
+		e := Join(left, right, dst, keyFn, combine, ext, WithJoin(c.jt))
+		if e != nil {
+			t.Fatal(e)
+		}
+		var got []string
+		e = forEachRaw(dst, nil, func(raw json.RawMessage) error {
+			var s string
+			got = append(got, s)
+			return json.Unmarshal(raw, &got[len(got)-1])
+		})
+		if e != nil {
+			t.Fatal(e)
+		}
+		if strings.Join(got, ",") != c.expected {
+			t.Fatalf("join type %d: expected %s, got %s", c.jt, c.expected, strings.Join(got, ","))
+		}
+	}
+
+	// Unsorted input.
+	e = WriteAll(filepath.Join(right, "r.ndjson"), []tt{{Name: "c"}, {Name: "b"}})
+	if e != nil {
+		t.Fatal(e)
+	}
+	e = Join(left, right, filepath.Join(dir, "out", "unsorted.json"), keyFn, combine, ext, WithJoin(OuterJoin))
+	if e == nil || !strings.Contains(e.Error(), "not sorted") {
+		t.Fatalf("expected not sorted error, got %v", e)
+	}
+}
@@ -0,0 +1,190 @@
+// Copyright (c) 2015 AKUALAB INC., All rights reserved.
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ju
+
+import (
+	"bufio"
+	"io"
+)
+
+// WithComments makes streamers accept JavaScript-style comments (JSONC). Line comments
+// starting with "//" and block comments enclosed in "/*" and "*/" are replaced with
+// whitespace before decoding. Comment markers inside strings are left alone.
+// Newlines are kept so that line numbers in errors do not change.
+func WithComments() Option {
+	return func(o *options) {
+		o.comments = true
+	}
+}
+
+const (
+	commentNone = iota
+	commentLine
+	commentBlock
+)
+
+// commentReader replaces comments with whitespace.
+type commentReader struct {
+	rc    io.ReadCloser
+	br    *bufio.Reader
+	state int
+	inStr bool
+	esc   bool
+	start bool // the '*' that opens a block comment is next
+	star  bool // the previous byte in a block comment is '*'
+}
+
+func newCommentReader(rc io.ReadCloser) *commentReader {
+	return &commentReader{rc: rc, br: bufio.NewReader(rc)}
+}
+
+func (c *commentReader) Read(p []byte) (int, error) {
+	n, err := c.br.Read(p)
+	c.scan(p[:n])
+	return n, err
+}
+
+// scan replaces the comments in p with whitespace.
+func (c *commentReader) scan(p []byte) {
+	for i, b := range p {
+		switch c.state {
+		case commentLine:
+			if b == '\n' {
+				c.state = commentNone
+			} else {
+				p[i] = ' '
+			}
+			continue
+		case commentBlock:
+			if c.star && b == '/' {
+				c.state = commentNone
+			}
+			c.star = b == '*' && !c.start
+			c.start = false
+			if b != '\n' {
+				p[i] = ' '
+			}
+			continue
+		}
+		if c.inStr {
+			switch {
+			case c.esc:
+				c.esc = false
+			case b == '\\':
+				c.esc = true
+			case b == '"':
+				c.inStr = false
+			}
+			continue
+		}
+		switch b {
+		case '"':
+			c.inStr = true
+		case '/':
+			var next byte
+			if i+1 < len(p) {
+				next = p[i+1]
+			} else if ahead, err := c.br.Peek(1); err == nil {
+				next = ahead[0]
+			}
+			switch next {
+			case '/':
+				c.state = commentLine
+				p[i] = ' '
+			case '*':
+				c.state = commentBlock
+				c.start = true
+				c.star = false
+				p[i] = ' '
+			}
+		}
+	}
+}
+
+func (c *commentReader) Close() error {
+	return c.rc.Close()
+}
+
+// WithRelaxedSyntax makes streamers accept trailing commas in objects and arrays, as in
+// {"a": [1, 2,],}, which are common in hand-edited files. A comma followed by a closing brace or
+// bracket is replaced with whitespace before decoding. Commas inside strings are left alone.
+// Combine with WithComments to also accept comments, including between the comma and the bracket.
+func WithRelaxedSyntax() Option {
+	return func(o *options) {
+		o.relaxed = true
+	}
+}
+
+// commaReader replaces trailing commas with whitespace.
+type commaReader struct {
+	rc    io.ReadCloser
+	br    *bufio.Reader
+	inStr bool
+	esc   bool
+}
+
+func newCommaReader(rc io.ReadCloser) *commaReader {
+	return &commaReader{rc: rc, br: bufio.NewReader(rc)}
+}
+
+func (c *commaReader) Read(p []byte) (int, error) {
+	n, err := c.br.Read(p)
+	c.scan(p[:n])
+	return n, err
+}
+
+// scan replaces the trailing commas in p with whitespace.
+func (c *commaReader) scan(p []byte) {
+	for i, b := range p {
+		if c.inStr {
+			switch {
+			case c.esc:
+				c.esc = false
+			case b == '\\':
+				c.esc = true
+			case b == '"':
+				c.inStr = false
+			}
+			continue
+		}
+		switch b {
+		case '"':
+			c.inStr = true
+		case ',':
+			next := c.next(p[i+1:])
+			if next == '}' || next == ']' {
+				p[i] = ' '
+			}
+		}
+	}
+}
+
+// next returns the first byte that is not whitespace in p or, if there is none,
+// in the data that follows. Returns 0 if there is none.
+func (c *commaReader) next(p []byte) byte {
+	for _, b := range p {
+		if !isSpace(b) {
+			return b
+		}
+	}
+	for k := 1; ; k++ {
+		ahead, err := c.br.Peek(k)
+		if err != nil {
+			return 0
+		}
+		if b := ahead[k-1]; !isSpace(b) {
+			return b
+		}
+	}
+}
+
+func (c *commaReader) Close() error {
+	return c.rc.Close()
+}
+
+func isSpace(b byte) bool {
+	return b == ' ' || b == '\t' || b == '\n' || b == '\r'
+}
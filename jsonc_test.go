@@ -0,0 +1,147 @@
+// Copyright (c) 2015 AKUALAB INC., All rights reserved.
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ju
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+const jsoncData = `// config
+{
+  "Name": "a // not a comment", /* block
+  comment with "quotes" and */
+  "N": 1, // trailing
+  "Words": ["/*x*/", "y\"//"] /**/
+}
+/*/ still a comment */ {"N": 2}/**/
+`
+
+func TestComments(t *testing.T) {
+
+	fn := filepath.Join(os.TempDir(), "jsonc", "c.json")
+	e := os.MkdirAll(filepath.Dir(fn), 0777)
+	if e != nil {
+		t.Fatal(e)
+	}
+	e = os.WriteFile(fn, []byte(jsoncData), 0644)
+	if e != nil {
+		t.Fatal(e)
+	}
+	expected := []tt{{Name: "a // not a comment", N: 1, Words: []string{"/*x*/", "y\"//"}}, {N: 2}}
+
+	js, err := NewJSONStreamer(fn, WithComments())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer js.Close()
+	for i := 0; ; i++ {
+		var v tt
+		err := js.Next(&v)
+		if err == Done {
+			if i != len(expected) {
+				t.Fatalf("expected %d objects, got %d", len(expected), i)
+			}
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !v.equal(expected[i]) {
+			t.Fatalf("expected %v, got %v", expected[i], v)
+		}
+	}
+
+	js, err = NewJSONStreamer(fn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer js.Close()
+	var v tt
+	if js.Next(&v) == nil {
+		t.Fatal("expected syntax error without WithComments")
+	}
+}
+
+func TestCommentReaderSmallReads(t *testing.T) {
+
+	// Comment markers split across reads.
+	r := newCommentReader(io.NopCloser(iotest.OneByteReader(strings.NewReader(jsoncData))))
+	b, err := io.ReadAll(iotest.OneByteReader(r))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(b) != len(jsoncData) || strings.Count(string(b), "\n") != strings.Count(jsoncData, "\n") {
+		t.Fatalf("comments must be replaced in place, got %q", b)
+	}
+	if strings.Contains(string(b), "config") || strings.Contains(string(b), "trailing") || strings.Contains(string(b), "still") {
+		t.Fatalf("comment not removed: %q", b)
+	}
+	if !strings.Contains(string(b), `"a // not a comment"`) || !strings.Contains(string(b), `"/*x*/"`) {
+		t.Fatalf("string modified: %q", b)
+	}
+}
+
+func TestRelaxedSyntax(t *testing.T) {
+
+	fn := filepath.Join(os.TempDir(), "jsonc", "r.json")
+	data := `{"Name": "a,}", "N": 1, "Words": ["x", "y",
+	],}
+{"Name": "b", "Attrs": {"k": [[1,], {"z": [],},],},}
+{"N": 3, /* comment */ "Words": ["c", // comment
+],
+}
+`
+	e := os.WriteFile(fn, []byte(data), 0644)
+	if e != nil {
+		t.Fatal(e)
+	}
+	type attrs struct {
+		tt
+		Attrs map[string]interface{}
+	}
+
+	js, err := NewJSONStreamer(fn, WithRelaxedSyntax(), WithComments())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer js.Close()
+	var v attrs
+	for _, expected := range []string{
+		`{"Name":"a,}","N":1,"Words":["x","y"],"Attrs":null}`,
+		`{"Name":"b","N":0,"Words":null,"Attrs":{"k":[[1],{"z":[]}]}}`,
+		`{"Name":"","N":3,"Words":["c"],"Attrs":null}`,
+	} {
+		v = attrs{}
+		err := js.Next(&v)
+		if err != nil {
+			t.Fatal(err)
+		}
+		b, _ := json.Marshal(v)
+		if string(b) != expected {
+			t.Fatalf("expected %s, got %s", expected, b)
+		}
+	}
+	if e := js.Next(&v); e != Done {
+		t.Fatalf("expected Done, got %v", e)
+	}
+
+	// Commas followed by whitespace across reads.
+	in := "[1, \n\n  ]\n{\"a\":1 ,\t}"
+	r := newCommaReader(io.NopCloser(iotest.OneByteReader(strings.NewReader(in))))
+	b, err := io.ReadAll(iotest.OneByteReader(r))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != "[1  \n\n  ]\n{\"a\":1  \t}" {
+		t.Fatalf("unexpected output %q", b)
+	}
+}
@@ -8,16 +8,22 @@ package ju
 
 import (
 	"bufio"
+	"compress/flate"
+	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"io"
-	"log"
+	"io/fs"
+	"math/rand"
 	"os"
 	"path/filepath"
 	"reflect"
 	"regexp"
+	"runtime"
 	"strings"
 	"sync"
+	"time"
 
 	gzip "github.com/klauspost/pgzip"
 )
@@ -25,10 +31,51 @@ import (
 // Done is returned as the error value when there are no more objects to process.
 var Done = errors.New("no more json objects")
 
+// ErrClosedWriter is returned when using a Writer after Close.
+var ErrClosedWriter = errors.New("writer is closed")
+
+// ErrClosedStreamer is returned when calling Next on a JSONStreamer after Close.
+var ErrClosedStreamer = errors.New("streamer is closed")
+
+// ErrEmpty is returned by ReadJSONFile when the file contains no json value.
+var ErrEmpty = errors.New("no json value")
+
+// FileError records an error and the file that caused it. Errors returned when opening,
+// reading, or decoding a file are wrapped in a FileError. Use errors.As to get the path.
+type FileError struct {
+	Path string
+	Err  error
+}
+
+func (e *FileError) Error() string {
+	var pe *fs.PathError
+	if errors.As(e.Err, &pe) && pe.Path == e.Path {
+		return e.Err.Error()
+	}
+	return e.Path + ": " + e.Err.Error()
+}
+
+// Unwrap returns the underlying error.
+func (e *FileError) Unwrap() error {
+	return e.Err
+}
+
+// fileError wraps err in a FileError for path. Returns err unchanged if it is nil, io.EOF,
+// context.Canceled, or already wraps a FileError.
+func fileError(path string, err error) error {
+	var fe *FileError
+	if err == nil || err == io.EOF || err == context.Canceled || errors.As(err, &fe) {
+		return err
+	}
+	return &FileError{Path: path, Err: err}
+}
+
 // ReadJSON unmarshals json data from an io.Reader.
 // The param "o" must be a pointer to an object.
+// If r is empty or contains only whitespace, o is left unmodified and ReadJSON returns nil.
+// Use ReadJSONStrictOne to reject empty input.
 func ReadJSON(r io.Reader, o interface{}) error {
-	dec := json.NewDecoder(r)
+	dec := DefaultCodec.NewDecoder(r)
 	err := dec.Decode(o)
 	if err != nil && err != io.EOF {
 		return err
@@ -36,15 +83,52 @@ func ReadJSON(r io.Reader, o interface{}) error {
 	return nil
 }
 
-// ReadJSONFile unmarshals json data from a file.
+// ReadJSONStrictOne is like ReadJSON but expects exactly one json value in r.
+// It returns an error if r is empty or if there is any non-whitespace data after the first value.
+func ReadJSONStrictOne(r io.Reader, o interface{}) error {
+	dec := DefaultCodec.NewDecoder(r)
+	err := dec.Decode(o)
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+	if err != nil {
+		return err
+	}
+	var extra json.RawMessage
+	err = dec.Decode(&extra)
+	if err == io.EOF {
+		return nil
+	}
+	return fmt.Errorf("unexpected data after json value")
+}
+
+// ReadJSONFile unmarshals json data from a file. Gzipped files are detected by their
+// content and decompressed, whatever their extension.
+// If the file is empty or contains only whitespace, o is left unmodified and
+// the error wraps ErrEmpty.
 func ReadJSONFile(fn string, o interface{}) error {
 
 	f, err := os.Open(fn)
 	if err != nil {
 		return err
 	}
-	e := ReadJSON(f, o)
+	br := bufio.NewReader(f)
+	var r io.Reader = br
+	if magic, _ := br.Peek(2); len(magic) == 2 && magic[0] == 0x1f && magic[1] == 0x8b {
+		gz, err := gzip.NewReader(br)
+		if err != nil {
+			f.Close()
+			return err
+		}
+		defer gz.Close()
+		r = gz
+	}
+	e := DefaultCodec.NewDecoder(r).Decode(o)
+	if e == io.EOF {
+		e = fmt.Errorf("%s: %w", fn, ErrEmpty)
+	}
 	if e != nil {
+		f.Close()
 		return e
 	}
 	e = f.Close()
@@ -54,7 +138,7 @@ func ReadJSONFile(fn string, o interface{}) error {
 // WriteJSON writes an object to an io.Writer.
 func WriteJSON(w io.Writer, o interface{}) error {
 
-	enc := json.NewEncoder(w)
+	enc := DefaultCodec.NewEncoder(w)
 	err := enc.Encode(o)
 	if err != nil {
 		return err
@@ -62,75 +146,374 @@ func WriteJSON(w io.Writer, o interface{}) error {
 	return nil
 }
 
-// WriteJSONFile writes to a file.
-func WriteJSONFile(fn string, o interface{}) error {
+// WriteJSONFile writes to a file. If the ext is "gz", the data is gzipped.
+// Use WithFileMode and WithDirMode to set the permissions of created files and directories.
+func WriteJSONFile(fn string, o interface{}, opts ...Option) error {
 
-	e := os.MkdirAll(filepath.Dir(fn), 0755)
-	if e != nil {
-		return e
+	op := newOptions(opts...)
+	if op.err != nil {
+		return op.err
 	}
-	f, err := os.Create(fn)
+	f, err := op.create(fn)
 	if err != nil {
 		return err
 	}
-	ee := WriteJSON(f, o)
+	if filepath.Ext(fn) != ".gz" {
+		ee := WriteJSON(f, o)
+		if ee != nil {
+			f.Close()
+			return ee
+		}
+		return f.Close()
+	}
+	gz := gzip.NewWriter(f)
+	ee := WriteJSON(gz, o)
+	if ee == nil {
+		ee = gz.Close()
+	}
 	if ee != nil {
+		f.Close()
 		return ee
 	}
-	e = f.Close()
+	e := f.Close()
 	return e
 }
 
 // JSONStreamer will unmarshal a stream of JSON objects.
+// Each file is decoded separately so that an object cannot span two files.
 type JSONStreamer struct {
-	fs  io.ReadCloser
-	dec *json.Decoder
+	fs      io.ReadCloser
+	m       *multi
+	dec     Decoder
+	opts    *options
+	n       int // number of objects returned
+	skip    int // number of objects left to skip
+	done    bool
+	raw     json.RawMessage // reused buffer for skipped and filtered objects
+	skipped int64           // bytes of corrupt records skipped
+	closed  bool
+	source  string // file of the last decoded object
+	offset  int64  // input offset after the last decoded object
+	path    string
+	ext     []string
+
+	files    int   // number of files opened
+	fileObjs int64 // objects decoded from the current file
 }
 
 // NewJSONStreamer creates a new streamer to read json objects.
-// See FileStreamer to specify the path.
-func NewJSONStreamer(path string) (*JSONStreamer, error) {
-	fs, err := FileStreamer(path, ".json")
+// See FileStreamer to specify the path. Empty files contain no objects; if all
+// the files are empty, the first call to Next returns Done.
+func NewJSONStreamer(path string, opts ...Option) (*JSONStreamer, error) {
+	return newJSONStreamer(path, []string{".json"}, newOptions(opts...))
+}
+
+// NewJSONStreamerExt is like NewJSONStreamer but reads the files with the given extensions
+// instead of ".json". See FileStreamer for ext.
+func NewJSONStreamerExt(path string, ext ...string) (*JSONStreamer, error) {
+	return newJSONStreamer(path, ext, newOptions())
+}
+
+// NewJSONStreamerExtOpts is like NewJSONStreamerExt but also takes options.
+func NewJSONStreamerExtOpts(path string, ext []string, opts ...Option) (*JSONStreamer, error) {
+	return newJSONStreamer(path, ext, newOptions(opts...))
+}
+
+// NewJSONStreamerReadCloser creates a streamer that reads json objects from rc, for example a
+// reader that was already opened and wrapped by the caller. Close closes rc. The data is not
+// decompressed. Methods that need the files, such as EstimateCount, return an error.
+func NewJSONStreamerReadCloser(rc io.ReadCloser) *JSONStreamer {
+	o := newOptions()
+	r := o.wrap(rc)
+	return &JSONStreamer{
+		fs:   r,
+		dec:  o.newDecoder(r),
+		opts: o,
+	}
+}
+
+func newJSONStreamer(path string, ext []string, o *options) (*JSONStreamer, error) {
+	m, err := newMulti(path, ext, o)
 	if err != nil {
 		return nil, err
 	}
 	js := &JSONStreamer{
-		fs:  fs,
-		dec: json.NewDecoder(fs),
+		fs:   m,
+		m:    m,
+		opts: o,
+		skip: o.skip,
+		path: path,
+		ext:  ext,
 	}
 	return js, nil
 }
 
+// Resetter is implemented by decode targets that can be reused.
+// Decoding into an existing value keeps the fields that are missing in the next object
+// and adds to existing maps. Reset must clear the value, it may keep the allocated memory
+// (e.g. s.Words = s.Words[:0] or clear(s.Attrs)).
+type Resetter interface {
+	Reset()
+}
+
 // Next returns the next JSON object.
 // When there are no more results, Done is returned as the error.
+// To reduce allocations, pass the same dst on every call and implement Resetter;
+// Next calls Reset before decoding into dst.
 func (js *JSONStreamer) Next(dst interface{}) error {
-	e := js.dec.Decode(dst)
+	if js.closed {
+		return ErrClosedStreamer
+	}
+	if js.done {
+		return Done
+	}
+	for ; js.skip > 0; js.skip-- {
+		e := js.decodeNext(&js.raw)
+		if e == io.EOF {
+			js.done = true
+			return Done
+		}
+		if e != nil {
+			return e
+		}
+	}
+	if r, ok := dst.(Resetter); ok {
+		r.Reset()
+	}
+	e := js.decode(dst)
 	if e == io.EOF {
+		js.done = true
 		return Done
 	}
-	return e
+	if e != nil {
+		return e
+	}
+	js.n++
+	if js.opts.heartbeatN > 0 && js.n%js.opts.heartbeatN == 0 {
+		js.opts.heartbeat(int64(js.n))
+	}
+	if js.opts.limit > 0 && js.n >= js.opts.limit {
+		// Release the files now, the caller still has to call Close.
+		js.done = true
+		return js.fs.Close()
+	}
+	return nil
+}
+
+// decodeNext decodes the next object in the stream, moving on to the next file as needed.
+func (js *JSONStreamer) decodeNext(v interface{}) error {
+	for {
+		if js.dec == nil {
+			if js.m == nil {
+				return io.EOF
+			}
+			err := js.openNext()
+			if err != nil {
+				return err
+			}
+		}
+		e := js.dec.Decode(v)
+		if e == io.EOF && js.m != nil {
+			js.dec = nil
+			continue
+		}
+		if js.m != nil {
+			e = fileError(js.m.name, e)
+		}
+		if e == nil && js.m != nil {
+			js.m.object()
+			js.fileObjs++
+			js.source = js.m.name
+			js.offset = -1
+			if d, ok := js.dec.(interface{ InputOffset() int64 }); ok {
+				js.offset = d.InputOffset()
+			}
+		}
+		return e
+	}
+}
+
+// openNext opens the next file and creates its decoder.
+func (js *JSONStreamer) openNext() error {
+	r, err := js.m.nextFile()
+	if err != nil {
+		return err
+	}
+	js.files++
+	js.fileObjs = 0
+	if js.opts.recover {
+		js.dec = js.opts.limitRecords(r, func(r io.Reader) Decoder {
+			return js.newLineDecoder(r)
+		})
+	} else {
+		js.dec = js.opts.newDecoder(r)
+	}
+	return nil
+}
+
+// NextWithSource is like Next but also returns the file the object was read from
+// and the decoder's input offset in that file, which is the position just past the end
+// of the object in the uncompressed data. For archives, source is the entry name.
+// The offset is -1 if the Decoder of DefaultCodec does not implement InputOffset.
+func (js *JSONStreamer) NextWithSource(dst interface{}) (source string, offset int64, err error) {
+	err = js.Next(dst)
+	if err != nil {
+		return "", 0, err
+	}
+	return js.source, js.offset, nil
+}
+
+// EstimateCount returns the number of objects the streamer returns from the start, taking
+// WithSkip and WithLimit into account. It reads all the files in a separate pass and does not
+// consume the stream. Counting is cheaper than decoding since values are only scanned, but
+// every file must still be read, and gzipped or encrypted files must be decompressed or decrypted.
+// Objects that are later skipped by WithSkipInvalid are counted. The pass is not limited by
+// WithRateLimit and does not use the tokens of the stream.
+func (js *JSONStreamer) EstimateCount() (int64, error) {
+	if js.m == nil {
+		return 0, errors.New("cannot count the objects of a reader")
+	}
+	o := *js.opts
+	o.skip, o.limit = 0, 0
+	o.filters, o.valueHooks = nil, nil
+	o.progress, o.stats = nil, false
+	o.heartbeat, o.heartbeatN = nil, 0
+	o.rate = nil
+	c, err := newJSONStreamer(js.path, js.ext, &o)
+	if err != nil {
+		return 0, err
+	}
+	defer c.Close()
+	var n int64
+	for {
+		err := c.Next(&discard{})
+		if err == Done {
+			break
+		}
+		if err != nil {
+			return n, err
+		}
+		n++
+	}
+	n -= int64(js.opts.skip)
+	if n < 0 {
+		n = 0
+	}
+	if js.opts.limit > 0 && n > int64(js.opts.limit) {
+		n = int64(js.opts.limit)
+	}
+	return n, nil
+}
+
+// discard is a decode target that ignores the value.
+type discard struct{}
+
+func (*discard) UnmarshalJSON([]byte) error { return nil }
+
+// decode reads the next object into dst. If any filters are set, the object is
+// read as raw json and passed through the filters before unmarshaling.
+// The value hooks are called after unmarshaling.
+// Invalid objects are skipped when WithSkipInvalid is set.
+func (js *JSONStreamer) decode(dst interface{}) error {
+	for {
+		var e error
+		if len(js.opts.filters) == 0 {
+			e = js.decodeNext(dst)
+			if e != nil {
+				return e
+			}
+		} else {
+			e = js.decodeNext(&js.raw)
+			if e != nil {
+				return e
+			}
+			var raw json.RawMessage
+			raw, e = js.opts.filter(js.raw)
+			if e == nil {
+				e = DefaultCodec.Unmarshal(raw, dst)
+			} else if js.m != nil {
+				e = fileError(js.m.name, e)
+			}
+		}
+		if e == nil {
+			e = js.opts.hook(dst)
+		}
+		if e != nil && js.opts.skipInvalid && errors.Is(e, ErrInvalid) {
+			js.opts.logger.Warn("skipping invalid object", "error", e)
+			if r, ok := dst.(Resetter); ok {
+				r.Reset()
+			}
+			continue
+		}
+		return e
+	}
+}
+
+// forEachRaw calls fn for each json object in path. See FileStreamer for path and ext.
+func forEachRaw(path string, ext []string, fn func(raw json.RawMessage) error) error {
+	js, err := newJSONStreamer(path, ext, newOptions())
+	if err != nil {
+		return err
+	}
+	defer js.Close()
+	for {
+		var raw json.RawMessage
+		err := js.Next(&raw)
+		if err == Done {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		err = fn(raw)
+		if err != nil {
+			return err
+		}
+	}
 }
 
 // Close the JSON streamer. Will close the underlyign readers.
 func (js *JSONStreamer) Close() error {
+	if js.closed {
+		return nil
+	}
+	js.closed = true
 	return js.fs.Close()
 }
 
-// We can pass a list of files in various ways. See FileStreamer documentation.
-// This function returns a slice of file paths.
-func extractPaths(path string, ext ...string) ([]string, error) {
-	files := []string{}
-	r, e := regexp.Compile("^[^.].*[.][[:alnum:]]+")
-	if e != nil {
-		return nil, e
+// Filenames must not start with a period and must have an extension.
+var fileNameRegexp = regexp.MustCompile("^[^.].*[.][[:alnum:]]+")
+
+// ExpandPaths returns the files that FileStreamer would read for path and ext, in order.
+// For archives, it returns the names of the matching entries.
+func ExpandPaths(path string, ext ...string) ([]string, error) {
+	if filepath.Ext(path) != ".zip" && !isTar(path) {
+		return extractPaths(path, newOptions(), ext...)
 	}
-	allowed := map[string]bool{".gz": true}
-	for _, v := range ext {
-		if !strings.HasPrefix(v, ".") {
-			v = "." + v
+	src, err := newSource(path, ext, newOptions())
+	if err != nil {
+		return nil, err
+	}
+	defer src.Close()
+	names := []string{}
+	for {
+		name, r, err := src.next()
+		if err == io.EOF {
+			return names, nil
 		}
-		allowed[v] = true
+		if err != nil {
+			return nil, err
+		}
+		r.Close()
+		names = append(names, name)
 	}
+}
+
+// We can pass a list of files in various ways. See FileStreamer documentation.
+// This function returns a slice of file paths.
+func extractPaths(path string, o *options, ext ...string) ([]string, error) {
+	files := []string{}
+	allowed := allowedExt(ext...)
 	fi, err := os.Stat(path)
 	if err != nil {
 		return nil, err
@@ -138,14 +521,26 @@ func extractPaths(path string, ext ...string) ([]string, error) {
 	fext := filepath.Ext(path)
 	switch {
 	case fi.IsDir():
-		filepath.Walk(path, func(fn string, info os.FileInfo, err error) error {
-			if !r.MatchString(filepath.Base(fn)) {
+		// Walk does not follow a symbolic link passed as the root, resolve it
+		// and report the files under the original path.
+		root, err := filepath.EvalSymlinks(path)
+		if err != nil {
+			return nil, err
+		}
+		filepath.Walk(root, func(fn string, info os.FileInfo, err error) error {
+			if !fileNameRegexp.MatchString(filepath.Base(fn)) {
 				return nil
 			}
 			ext := filepath.Ext(fn)
 			if !matchExt(ext, allowed) {
 				return nil
 			}
+			if info == nil || !o.regular(fn, info) {
+				return nil
+			}
+			if rel, err := filepath.Rel(root, fn); err == nil {
+				fn = filepath.Join(path, rel)
+			}
 			files = append(files, fn)
 			return nil
 		})
@@ -157,9 +552,11 @@ func extractPaths(path string, ext ...string) ([]string, error) {
 		}
 		defer f.Close()
 		scanner := bufio.NewScanner(f)
-		files := []string{}
 		for scanner.Scan() {
-			line := scanner.Text()
+			line := strings.TrimSpace(scanner.Text())
+			if line == "" {
+				continue
+			}
 			files = append(files, line)
 		}
 		if err := scanner.Err(); err != nil {
@@ -174,20 +571,160 @@ func extractPaths(path string, ext ...string) ([]string, error) {
 // FileStreamer returns a reader that streams data from multiple files. The list of files can be specified in multiple ways:
 // (1) path is a single file. The file may be gzipped in which case the name extension must be ".gz".
 // (2) path is a directory. Reads from all the files in that directory such that (a) the filename must not start with a period,
-// (b) the filename has extension ".gz", (c) the "ext" parameter is empty or the allowed extensions are listed, (d) path is not a symboic link.
+// (b) the filename has extension ".gz" (or ".zdict", see WithDictionary), (c) the "ext" parameter is empty or the allowed extensions are listed, (d) path is not a symboic link,
+// (e) the file is a regular file or a symbolic link to one; named pipes, sockets, and devices are skipped unless WithNonRegularFiles is set.
+// If path itself is a symbolic link, it is resolved unless WithRejectSymlinks is set.
 // (3) path is a file with extension ".list" that contains a list of paths to files. Read from all the files in the list.
+// (4) path is a zip archive with extension ".zip". Reads from all the entries in the archive, in the order listed in the
+// archive, using the same rules as for a directory. Entries with extension ".gz" are decompressed.
+// (5) path is a tar archive with extension ".tar", ".tar.gz", or ".tgz". Reads from all the regular file entries in the
+// archive using the same rules as for a zip archive. Directory and symbolic link entries are skipped.
+//
+// Blank lines in a ".list" file are ignored.
+//
+// A UTF-8 byte order mark at the beginning of a file is removed.
+//
+// If path does not exist, the error satisfies errors.Is(err, os.ErrNotExist). Other errors, such as
+// permission errors, do not. If no files match (an empty directory, an empty list, or an extension
+// filter that excludes all files), the stream is empty and the first Read returns io.EOF.
 //
 // The return value is of type io.ReadCloser. It is the caller's responsibility to call Close on the ReadCloser when done.
+// Close must also be called when the caller stops reading early, or the current file stays open.
+// As a safety net, a reader that is garbage collected without being closed logs a warning and
+// closes its files. Use StreamFunc to guarantee that the reader is closed.
+// It also implements io.WriterTo, so io.Copy copies the files without an intermediate buffer.
+//
+// As allowed by io.Reader, Read may return less data than requested; in particular, a Read never
+// returns data from two files. Use io.ReadFull, or NewFileStreamer with WithFullReads, if the
+// buffer must be filled.
 func FileStreamer(path string, ext ...string) (io.ReadCloser, error) {
-	paths, err := extractPaths(path, ext...)
+	return NewFileStreamer(path, ext)
+}
+
+// FileStreamerN is like FileStreamer but also returns the number of files that matched,
+// so callers can tell an empty stream from a path that matched nothing.
+// For tar archives the number of entries is not known in advance and n is -1.
+func FileStreamerN(path string, ext ...string) (rc io.ReadCloser, n int, err error) {
+	m, err := newMulti(path, ext, newOptions())
+	if err != nil {
+		return nil, 0, err
+	}
+	return m, m.src.total(), nil
+}
+
+// NewFileStreamer is like FileStreamer but also accepts options.
+func NewFileStreamer(path string, ext []string, opts ...Option) (io.ReadCloser, error) {
+	return newMulti(path, ext, newOptions(opts...))
+}
+
+// StreamFunc calls fn with a reader created by FileStreamer and closes the reader when fn
+// returns, even if fn stops reading early. It returns the error of fn, or else the error of Close.
+func StreamFunc(path string, fn func(io.ReadCloser) error, ext ...string) error {
+	r, err := FileStreamer(path, ext...)
+	if err != nil {
+		return err
+	}
+	err = fn(r)
+	e := r.Close()
+	if err != nil {
+		return err
+	}
+	return e
+}
+
+func newMulti(path string, ext []string, o *options) (*multi, error) {
+	if o.err != nil {
+		return nil, o.err
+	}
+	src, err := newSource(path, ext, o)
+	if err != nil {
+		return nil, err
+	}
+	return newMultiSource(src, o), nil
+}
+
+// newMultiSource returns a multi reader for src. A finalizer closes the reader if its
+// owner does not.
+func newMultiSource(src source, o *options) *multi {
+	m := &multi{src: src, opts: o}
+	runtime.SetFinalizer(m, (*multi).finalize)
+	return m
+}
+
+// finalize closes a multi reader that was not closed by its owner.
+func (m *multi) finalize() {
+	if m.closed {
+		return
+	}
+	m.opts.logger.Warn("file streamer was not closed", "path", m.name)
+	m.Close()
+}
+
+// newSource returns the source for path. See FileStreamer.
+func newSource(path string, ext []string, o *options) (source, error) {
+	err := o.checkSymlink(path)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case filepath.Ext(path) == ".zip":
+		zs, err := newZipSource(path, ext...)
+		if err != nil {
+			return nil, err
+		}
+		zs.files = selectFiles(zs.files, o)
+		return zs, nil
+	case isTar(path):
+		ts, err := newTarSource(path, ext...)
+		if err != nil {
+			return nil, err
+		}
+		ts.max = o.maxFiles
+		return ts, nil
+	}
+	paths, err := extractPaths(path, o, ext...)
 	if err != nil {
 		return nil, err
 	}
-	return &multi{files: paths}, nil
+	return &fileSource{files: selectFiles(paths, o), opts: o}, nil
+}
+
+// selectFiles returns the files to read from the sorted list of files.
+// See WithShuffle and WithMaxFiles.
+func selectFiles[T any](files []T, o *options) []T {
+	if o.shuffle {
+		files = append([]T(nil), files...)
+		rng := rand.New(rand.NewSource(o.seed))
+		rng.Shuffle(len(files), func(i, j int) {
+			files[i], files[j] = files[j], files[i]
+		})
+	}
+	if o.maxFiles > 0 && len(files) > o.maxFiles {
+		files = files[:o.maxFiles]
+	}
+	return files
+}
+
+// allowedExt returns the set of allowed extensions. An empty set allows all the extensions;
+// otherwise, the compressed extensions ".gz" and ".zdict" are always allowed.
+func allowedExt(ext ...string) map[string]bool {
+	allowed := map[string]bool{}
+	if len(ext) == 0 {
+		return allowed
+	}
+	allowed[".gz"] = true
+	allowed[dictExt] = true
+	for _, v := range ext {
+		if !strings.HasPrefix(v, ".") {
+			v = "." + v
+		}
+		allowed[v] = true
+	}
+	return allowed
 }
 
 func matchExt(ext string, allowed map[string]bool) bool {
-	if len(allowed) == 1 {
+	if len(allowed) == 0 {
 		return true
 	}
 	_, ok := allowed[ext]
@@ -197,96 +734,297 @@ func matchExt(ext string, allowed map[string]bool) bool {
 	return false
 }
 
+// source iterates over the readers that make up a multi stream.
+// Method next returns io.EOF when there are no more readers.
+// Method total returns the number of readers, or -1 if unknown.
+type source interface {
+	next() (name string, r io.ReadCloser, err error)
+	total() int
+	Close() error
+}
+
+// fileSource reads from a list of files.
+type fileSource struct {
+	files   []string
+	idx     int
+	opts    *options
+	missing []string // files skipped because they do not exist
+}
+
+func (fs *fileSource) next() (string, io.ReadCloser, error) {
+	for {
+		if fs.idx >= len(fs.files) {
+			return "", nil, io.EOF
+		}
+		path := fs.files[fs.idx]
+		fs.idx++
+		r, err := streamFile(path, fs.opts)
+		if err != nil && fs.opts.skipMissing && errors.Is(err, os.ErrNotExist) {
+			fs.opts.logger.Warn("skipping missing file", "path", path)
+			fs.missing = append(fs.missing, path)
+			continue
+		}
+		if err != nil {
+			return "", nil, fileError(path, err)
+		}
+		return path, r, nil
+	}
+}
+
+func (fs *fileSource) total() int {
+	return len(fs.files) - len(fs.missing)
+}
+
+func (fs *fileSource) Close() error {
+	fs.idx = 0
+	fs.files = nil
+	return nil
+}
+
+// Missing returns the files that were skipped because they do not exist. See WithSkipMissing.
+func (js *JSONStreamer) Missing() []string {
+	if js.m == nil {
+		return nil
+	}
+	if fs, ok := js.m.src.(*fileSource); ok {
+		return fs.missing
+	}
+	return nil
+}
+
 type multi struct {
-	files  []string
-	idx    int
+	src    source
 	reader io.ReadCloser
+	name   string
+	opts   *options
+	done   int // number of readers read to the end
+	last   bool
+	stats  []FileStat
+	start  time.Time
+	closed bool
+}
+
+// open advances to the next reader. Returns io.EOF when there are no more readers.
+func (m *multi) open() error {
+	name, r, err := m.src.next()
+	if err == io.EOF && !m.last {
+		m.last = true
+		m.progress("")
+	}
+	if err != nil {
+		return err
+	}
+	m.reader = m.opts.wrap(r)
+	m.name = name
+	m.progress(name)
+	if m.opts.stats {
+		m.stats = append(m.stats, FileStat{Path: name})
+		m.start = time.Now()
+	}
+	return nil
+}
+
+// closeReader closes the current reader after reading it to the end.
+func (m *multi) closeReader() error {
+	err := fileError(m.name, m.reader.Close())
+	m.reader = nil
+	m.done++
+	if m.opts.stats {
+		m.stats[len(m.stats)-1].Duration = time.Since(m.start)
+	}
+	return err
+}
+
+// read reads from the current reader.
+func (m *multi) read(p []byte) (int, error) {
+	n, e := m.reader.Read(p)
+	if m.opts.stats {
+		m.stats[len(m.stats)-1].Bytes += int64(n)
+	}
+	return n, fileError(m.name, e)
+}
+
+// object records that an object was decoded from the current reader.
+func (m *multi) object() {
+	if m.opts.stats && len(m.stats) > 0 {
+		m.stats[len(m.stats)-1].Objects++
+	}
 }
 
 func (m *multi) Read(p []byte) (int, error) {
-	if len(m.files) == 0 {
-		return 0, io.EOF
+	if !m.opts.fullReads {
+		return m.readOnce(p)
 	}
-	if m.reader == nil {
-		// Edge case, calling Read after last reader is closed.
-		if m.idx >= len(m.files) {
-			return 0, io.EOF
+	var n int
+	for n < len(p) {
+		k, e := m.readOnce(p[n:])
+		n += k
+		if e == io.EOF && n > 0 {
+			return n, nil
 		}
-		f, err := os.Open(m.files[m.idx])
-		if err != nil {
-			return 0, err
+		if e != nil {
+			return n, e
 		}
-		if filepath.Ext(m.files[m.idx]) == ".gz" {
-			m.reader, err = NewGZIPReader(f)
+	}
+	return n, nil
+}
+
+// readOnce reads from the current file, opening the next one as needed.
+// It returns at most the data left in one file.
+func (m *multi) readOnce(p []byte) (int, error) {
+	for {
+		if m.reader == nil {
+			err := m.open()
 			if err != nil {
 				return 0, err
 			}
-		} else {
-			m.reader = f
 		}
-		m.idx++
-	}
-	n, e := m.reader.Read(p)
-	switch {
+		n, e := m.read(p)
+		switch {
 
-	case e == nil:
-		// We are good.
-		return n, nil
+		case e == nil:
+			// We are good.
+			return n, nil
 
-	case e == io.EOF && m.idx < len(m.files):
-		// End of reader but we have more files.
-		err := m.reader.Close()
-		if err != nil {
-			return n, err
+		case e == io.EOF:
+			// End of reader, move on to the next one, if any.
+			err := m.closeReader()
+			if err != nil {
+				return n, err
+			}
+			if n > 0 {
+				return n, nil // we are not done yet!
+			}
+
+		default:
+			// Some unknown error.
+			m.reader.Close()
+			m.reader = nil
+			return n, e
 		}
-		m.reader = nil
-		return n, nil // we are not done yet!
+	}
+}
 
-	case e == io.EOF:
-		// End of last reader.
-		err := m.reader.Close()
+// WriteTo implements the io.WriterTo interface. It copies the remaining
+// data of all the files to w, so io.Copy does not need an intermediate buffer.
+func (m *multi) WriteTo(w io.Writer) (int64, error) {
+	var total int64
+	for {
+		if m.reader == nil {
+			err := m.open()
+			if err == io.EOF {
+				return total, nil
+			}
+			if err != nil {
+				return total, err
+			}
+		}
+		n, err := io.Copy(w, fileReader{m})
+		total += n
 		if err != nil {
-			return 0, err
+			m.reader.Close()
+			m.reader = nil
+			return total, err
 		}
-		m.reader = nil
-		return n, io.EOF // we are done!
+		err = m.closeReader()
+		if err != nil {
+			return total, err
+		}
+	}
+}
 
-	default:
-		// Some unknown error.
-		err := m.reader.Close()
+// nextFile returns a reader for the next file. Unlike Read, the returned reader
+// returns io.EOF at the end of the file. Returns io.EOF when there are no more files.
+func (m *multi) nextFile() (io.Reader, error) {
+	if m.reader != nil {
+		err := m.closeReader()
 		if err != nil {
-			return 0, err
+			return nil, err
 		}
-		return n, e
+	}
+	err := m.open()
+	if err != nil {
+		return nil, err
+	}
+	return fileReader{m}, nil
+}
+
+// fileReader reads the current file of a multi reader.
+type fileReader struct {
+	m *multi
+}
+
+func (f fileReader) Read(p []byte) (int, error) {
+	if f.m.reader == nil {
+		return 0, io.EOF
+	}
+	return f.m.read(p)
+}
+
+func (m *multi) progress(name string) {
+	if m.opts.progress != nil {
+		m.opts.progress(m.done, m.src.total(), name)
 	}
 }
 
 // Close closes the underlying resources.
 func (m *multi) Close() error {
-	m.idx = 0
-	m.files = nil
+	if !m.closed {
+		m.closed = true
+		runtime.SetFinalizer(m, nil)
+	}
+	var err error
 	if m.reader != nil {
-		err := m.reader.Close()
-		if err != nil {
-			return err
-		}
+		err = m.reader.Close()
+		m.reader = nil
 	}
-	return nil
+	e := m.src.Close()
+	if err != nil {
+		return err
+	}
+	return e
 }
 
-func streamFile(path string) (io.ReadCloser, error) {
-	f, e := os.Open(path)
+func streamFile(path string, o *options) (io.ReadCloser, error) {
+	f, e := openFile(path, o)
 	if e != nil {
 		return nil, e
 	}
+	return o.decode(path, f)
+}
+
+// decode applies the rate limit, decryption, and decompression configured for the file f.
+// The file is closed if an error occurs.
+func (o *options) decode(path string, f io.ReadCloser) (io.ReadCloser, error) {
+	var rc io.ReadCloser = f
+	var e error
+	if o.rate != nil {
+		rc = &rateReader{rc: f, l: o.rate, ctx: o.context()}
+	}
+	if o.key != nil {
+		rc, e = newDecryptReader(rc, o.key)
+		if e != nil {
+			f.Close()
+			return nil, e
+		}
+	}
+	if filepath.Ext(path) == dictExt {
+		e = o.checkDictExt(path)
+		if e != nil {
+			f.Close()
+			return nil, e
+		}
+		return newFlateReader(rc, o.dict), nil
+	}
 	if filepath.Ext(path) == ".gz" {
-		r, err := NewGZIPReader(f)
+		r, err := NewGZIPReader(rc)
 		if err != nil {
+			f.Close()
 			return nil, err
 		}
 		return r, nil
 	}
-	return f, nil
+	return rc, nil
 }
 
 // GZIPReader is a wrapper to read compressed gzip files.
@@ -295,12 +1033,22 @@ type GZIPReader struct {
 	gzipReader *gzip.Reader
 }
 
+// DefaultGZIPBufferSize is the size of the read buffer used by NewGZIPReader.
+const DefaultGZIPBufferSize = 64 * 1024
+
 // NewGZIPReader creates a new GZIPReader that reads from r.
 // The return value implements io.ReadCloser. It is the caller's responsibility to call Close when done.
 func NewGZIPReader(r io.ReadCloser) (*GZIPReader, error) {
+	return NewGZIPReaderSize(r, DefaultGZIPBufferSize)
+}
+
+// NewGZIPReaderSize is like NewGZIPReader but reads the compressed data from r
+// through a buffer of the given size. Larger buffers reduce the number of reads
+// on large files.
+func NewGZIPReaderSize(r io.ReadCloser, size int) (*GZIPReader, error) {
 	gr := &GZIPReader{inReader: r}
 	var err error
-	gr.gzipReader, err = gzip.NewReader(gr.inReader)
+	gr.gzipReader, err = gzip.NewReader(bufio.NewReaderSize(gr.inReader, size))
 	if err != nil {
 		return nil, err
 	}
@@ -327,99 +1075,269 @@ func (g *GZIPReader) Close() error {
 
 // ReadJSONParallel creates a new streamer to read json objects.
 // See FileStreamer to specify the path.
-// Run it on a seprate goroutine.
-func ReadJSONParallel(path string, obj interface{}, objCh chan interface{}, numWorkers int) {
+// Run it on a seprate goroutine. The objCh channel is closed when done.
+// If a file cannot be read, the remaining files are skipped and the first error is returned.
+// Each object sent on objCh is a new pointer to a value of the type of obj, owned by the receiver.
+// Call Recycle when an object is no longer needed to reduce allocations.
+func ReadJSONParallel(path string, obj interface{}, objCh chan interface{}, numWorkers int, opts ...Option) error {
+	return ReadJSONParallelContext(context.Background(), path, obj, objCh, numWorkers, opts...)
+}
+
+// ReadJSONParallelContext is like ReadJSONParallel but stops when ctx is cancelled.
+// On cancellation, workers stop reading files, objCh is closed after all workers
+// return, and the context error is returned.
+func ReadJSONParallelContext(ctx context.Context, path string, obj interface{}, objCh chan interface{}, numWorkers int, opts ...Option) error {
+
+	defer close(objCh)
+	o := newOptions(opts...)
+	if o.ctx == nil {
+		o.ctx = ctx
+	}
+	return parallelFiles(ctx, path, numWorkers, o, func(ctx context.Context, path string) error {
+		return worker(ctx, obj, path, objCh, o)
+	})
+}
+
+// parallelFiles calls fn for each file in path using numWorkers goroutines.
+// After the first error, the context passed to fn is cancelled and the remaining files are skipped.
+// Function fn must return context.Canceled when it stops because the context was cancelled.
+func parallelFiles(ctx context.Context, path string, numWorkers int, o *options, fn func(ctx context.Context, path string) error) error {
+
+	if o.err != nil {
+		return o.err
+	}
+	if numWorkers < 1 {
+		return fmt.Errorf("invalid number of workers: %d", numWorkers)
+	}
 
 	// List of filel paths.
-	paths, err := extractPaths(path, ".json")
+	err := o.checkSymlink(path)
 	if err != nil {
-		log.Fatal(err)
+		return err
 	}
+	paths, err := extractPaths(path, o, ".json")
+	if err != nil {
+		o.logger.Error("cannot list files", "path", path, "error", err)
+		return err
+	}
+	paths = selectFiles(paths, o)
+
+	// Workers stop after the first error.
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	var mu sync.Mutex
+	var firstErr error
 
 	// We need to know when all workers finish doing the work.
 	var wg sync.WaitGroup
 	wg.Add(numWorkers)
-	log.Printf("starting %d workers", numWorkers)
+	o.logger.Debug("starting workers", "workers", numWorkers)
 	pathCh := make(chan string, 10)
 
 	// Do the work concurrently in the background.
 	for w := 0; w < numWorkers; w++ {
 		go func() {
-			worker(obj, pathCh, objCh)
-			wg.Done()
+			defer wg.Done()
+			for path := range pathCh {
+				err := fileError(path, fn(ctx, path))
+				if err == context.Canceled {
+					return
+				}
+				if err != nil {
+					o.logger.Error("worker error when processing file", "path", path, "error", err)
+					mu.Lock()
+					if firstErr == nil {
+						firstErr = err
+					}
+					mu.Unlock()
+					cancel()
+					return
+				}
+			}
 		}()
 	}
 
 	// Push paths into channel so workers can do their job concurrently.
+push:
 	for _, v := range paths {
-		pathCh <- v
+		select {
+		case pathCh <- v:
+		case <-ctx.Done():
+			break push
+		}
 	}
 	// Signal that all work is in the channel.
 	close(pathCh)
 
 	// Wait for all workers to finish.
 	wg.Wait()
-	close(objCh)
+	if firstErr != nil {
+		return firstErr
+	}
+	return context.Cause(ctx)
 }
 
-func worker(obj interface{}, pathCh chan string, objCh chan interface{}) {
+func worker(ctx context.Context, obj interface{}, path string, objCh chan interface{}, o *options) error {
 
+	reader, err := streamFile(path, o)
+	if err != nil {
+		return err
+	}
+	reader = o.wrap(reader)
+	defer reader.Close()
+	dec := o.newDecoder(reader)
+	pool := objectPool(reflect.Indirect(reflect.ValueOf(obj)).Type())
+	n := 0
 	for {
-		path, more := <-pathCh
-		if !more {
-			return
+		if ctx.Err() != nil {
+			return context.Canceled
 		}
-		reader, err := streamFile(path)
-		if err != nil {
-			log.Fatalln("worker error when processing file ", path)
-		}
-		dec := json.NewDecoder(reader)
-		n := 0
-		for {
-			val := reflect.ValueOf(obj)
-			val = reflect.Indirect(val)
-			x := reflect.New(val.Type()).Interface()
-			e := dec.Decode(x)
-			if e == io.EOF {
-				// log.Printf("read %8d records from file %s", n, path)
-				break
-			}
-			objCh <- x
-			n++
+		x := pool.Get()
+		e := dec.Decode(x)
+		if e == io.EOF {
+			o.logger.Debug("read records", "records", n, "path", path)
+			return nil
 		}
+		if e != nil {
+			return e
+		}
+		select {
+		case objCh <- x:
+		case <-ctx.Done():
+			return context.Canceled
+		}
+		n++
 	}
 }
 
-// Writer writes json objects.
+// Pools of decode targets, one per type.
+var objectPools sync.Map // reflect.Type -> *sync.Pool
+
+func objectPool(typ reflect.Type) *sync.Pool {
+	if p, ok := objectPools.Load(typ); ok {
+		return p.(*sync.Pool)
+	}
+	p, _ := objectPools.LoadOrStore(typ, &sync.Pool{
+		New: func() interface{} { return reflect.New(typ).Interface() },
+	})
+	return p.(*sync.Pool)
+}
+
+// Recycle returns an object received from ReadJSONParallel so it can be reused
+// for another record. The caller must not use obj, or anything it references, after the call.
+// If obj implements Resetter, Reset is called and the memory it keeps is reused,
+// otherwise obj is set to its zero value.
+func Recycle(obj interface{}) {
+	v := reflect.ValueOf(obj)
+	if v.Kind() != reflect.Ptr || v.IsNil() {
+		return
+	}
+	if r, ok := obj.(Resetter); ok {
+		r.Reset()
+	} else {
+		v.Elem().SetZero()
+	}
+	objectPool(v.Elem().Type()).Put(obj)
+}
+
+// Writer writes json objects. A Writer is not safe for concurrent use;
+// use SyncWriter to share a Writer between goroutines.
 type Writer struct {
-	writer io.WriteCloser
+	file   *os.File
+	crypt  *encryptWriter
+	gz     *gzip.Writer
+	fl     *flate.Writer // replaces gz, see WithDictionary
+	b64    *base64LineWriter
 	path   string
-	enc    *json.Encoder
+	enc    Encoder
+	closed bool
+	flushN int // flush every flushN objects
+	n      int // objects written since the last flush
+
+	idField string // see WithAutoID
+	nextID  int64
+
+	index *indexWriter // see WithIndexSidecar
+
+	sortKeys bool // see WithSortedKeys
 }
 
 // NewWriter writes graphs to files.
 // path is the filename, if the ext is "gz", the data is gzipped.
-func NewWriter(path string) (*Writer, error) {
+func NewWriter(path string, opts ...Option) (*Writer, error) {
+	return NewWriterLevel(path, gzip.DefaultCompression, opts...)
+}
 
+// NewWriterLevel is like NewWriter but specifies the gzip compression level.
+// The level must be DefaultCompression, ConstantCompression, or any integer value
+// between BestSpeed and BestCompression inclusive (see package pgzip).
+// For paths whose ext is not "gz", the level is ignored.
+func NewWriterLevel(path string, level int, opts ...Option) (*Writer, error) {
+
+	o := newOptions(opts...)
+	if o.err != nil {
+		return nil, o.err
+	}
+	isGZ := filepath.Ext(path) == ".gz"
+	isDict := filepath.Ext(path) == dictExt
+	err := o.checkDictExt(path)
+	if err != nil {
+		return nil, err
+	}
+	if (isGZ || isDict) && (level < gzip.ConstantCompression || level > gzip.BestCompression) {
+		return nil, fmt.Errorf("invalid gzip compression level: %d", level)
+	}
 	writer := &Writer{
-		path: path,
+		path:     path,
+		flushN:   o.flushEvery,
+		idField:  o.autoID,
+		nextID:   o.autoIDStart,
+		sortKeys: o.sortKeys,
 	}
-	e := os.MkdirAll(filepath.Dir(path), 0755)
-	if e != nil {
-		return nil, e
+	if o.indexPath != "" && (isGZ || isDict || o.key != nil || o.base64 != nil) {
+		return nil, fmt.Errorf("cannot index %s, only plain files can be indexed", path)
 	}
-	w, e := os.Create(path)
+	w, e := o.create(path)
 	if e != nil {
 		return nil, e
 	}
 
-	writer.enc = json.NewEncoder(w)
-	writer.writer = w
-	if filepath.Ext(path) == ".gz" {
-		gz := gzip.NewWriter(w)
-		writer.enc = json.NewEncoder(gz)
-		writer.writer = gz
+	writer.file = w
+	var out io.Writer = w
+	if o.indexPath != "" {
+		writer.index = &indexWriter{w: w, path: o.indexPath}
+		out = writer.index
+	}
+	if o.key != nil {
+		writer.crypt, e = newEncryptWriter(w, o.key)
+		if e != nil {
+			w.Close()
+			return nil, e
+		}
+		out = writer.crypt
+	}
+	if isDict {
+		fl, err := flate.NewWriterDict(out, level, o.dict)
+		if err != nil {
+			w.Close()
+			return nil, err
+		}
+		writer.fl = fl
+		out = fl
+	} else if isGZ {
+		gz, err := gzip.NewWriterLevel(out, level)
+		if err != nil {
+			w.Close()
+			return nil, err
+		}
+		writer.gz = gz
+		out = gz
 	}
+	if o.base64 != nil {
+		writer.b64 = &base64LineWriter{w: out, enc: o.base64}
+		out = writer.b64
+	}
+	writer.enc = o.newEncoder(out)
 
 	return writer, nil
 }
@@ -427,17 +1345,152 @@ func NewWriter(path string) (*Writer, error) {
 // WriteJSON writes a json object.
 func (w *Writer) Write(o interface{}) error {
 
+	if w.closed {
+		return ErrClosedWriter
+	}
+	if w.idField != "" {
+		om, err := w.withID(o)
+		if err != nil {
+			return err
+		}
+		o = om
+	}
+	if w.sortKeys {
+		raw, err := sortKeys(o)
+		if err != nil {
+			return err
+		}
+		o = raw
+	}
+	var start int64
+	if w.index != nil {
+		start = w.index.n
+	}
 	err := w.enc.Encode(o)
 	if err != nil {
 		return err
 	}
+	if w.index != nil {
+		w.index.offsets = append(w.index.offsets, start)
+	}
+	if w.idField != "" {
+		w.nextID++
+	}
+	if w.flushN > 0 {
+		w.n++
+		if w.n >= w.flushN {
+			return w.Flush()
+		}
+	}
+	return nil
+}
+
+// Flush writes any pending data to the underlying file so that a reader can see
+// all the objects written so far. For gzipped files, Flush inserts a flush point
+// in the compressed stream; the stream remains valid and writing may continue.
+// Frequent flushing reduces the compression ratio.
+// For plain files, Flush is a no-op since objects are written directly to the file.
+// For encrypted files, Flush seals the buffered data.
+func (w *Writer) Flush() error {
+	if w.closed {
+		return ErrClosedWriter
+	}
+	w.n = 0
+	if w.gz != nil {
+		err := w.gz.Flush()
+		if err != nil {
+			return err
+		}
+	}
+	if w.fl != nil {
+		err := w.fl.Flush()
+		if err != nil {
+			return err
+		}
+	}
+	if w.crypt != nil {
+		return w.crypt.Flush()
+	}
 	return nil
 }
 
-// Close closes the writer.
+// Sync flushes the writer and commits the contents of the file to stable storage.
+func (w *Writer) Sync() error {
+	err := w.Flush()
+	if err != nil {
+		return err
+	}
+	return w.file.Sync()
+}
+
+// Close closes the writer and the underlying file. Calling Close again has no effect.
 func (w *Writer) Close() error {
-	if w.writer != nil {
-		return w.writer.Close()
+	if w.closed {
+		return nil
+	}
+	w.closed = true
+	if w.b64 != nil {
+		err := w.b64.Close()
+		if err != nil {
+			w.file.Close()
+			return err
+		}
+	}
+	if w.gz != nil {
+		err := w.gz.Close()
+		if err != nil {
+			w.file.Close()
+			return err
+		}
+	}
+	if w.fl != nil {
+		err := w.fl.Close()
+		if err != nil {
+			w.file.Close()
+			return err
+		}
+	}
+	if w.crypt != nil {
+		err := w.crypt.Close()
+		if err != nil {
+			w.file.Close()
+			return err
+		}
+	}
+	if w.file != nil {
+		err := w.file.Close()
+		if err != nil {
+			return err
+		}
+	}
+	if w.index != nil {
+		return WriteJSONFile(w.index.path, w.index.offsets)
+	}
+	return nil
+}
+
+// WriteAll writes a slice of objects to a file, one json object per line.
+// If the path ext is "gz", the data is gzipped. Parent directories are created as needed.
+// When "ext" is not empty, the path must have one of the listed extensions (or ".gz").
+func WriteAll[T any](path string, objs []T, ext ...string) error {
+
+	if len(ext) > 0 && !matchExt(filepath.Ext(path), allowedExt(ext...)) {
+		return fmt.Errorf("writing %s: extension not in %v", path, ext)
+	}
+	w, err := NewWriter(path)
+	if err != nil {
+		return fmt.Errorf("writing %s: %w", path, err)
+	}
+	for i := range objs {
+		err = w.Write(objs[i])
+		if err != nil {
+			w.Close()
+			return fmt.Errorf("writing %s: %w", path, err)
+		}
+	}
+	err = w.Close()
+	if err != nil {
+		return fmt.Errorf("writing %s: %w", path, err)
 	}
 	return nil
 }
@@ -6,13 +6,23 @@
 package ju
 
 import (
+	"bytes"
 	"compress/gzip"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"log/slog"
+	"net"
 	"os"
 	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 )
 
 type tt struct {
@@ -480,3 +490,1720 @@ func TestWrite(t *testing.T) {
 		}
 	}
 }
+
+func TestWriteGZ(t *testing.T) {
+
+	x := tt{Name: "gz", N: 3, Words: []string{"a", "b"}}
+	fn := filepath.Join(os.TempDir(), "write-gz", "x.json.gz")
+	e := WriteJSONFile(fn, x)
+	if e != nil {
+		t.Fatal(e)
+	}
+	b, err := os.ReadFile(fn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(b) < 2 || b[0] != 0x1f || b[1] != 0x8b {
+		t.Fatalf("file is not gzipped: %q", b)
+	}
+
+	js, err := NewJSONStreamer(fn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer js.Close()
+	var y tt
+	e = js.Next(&y)
+	if e != nil {
+		t.Fatal(e)
+	}
+	if !y.equal(x) {
+		t.Fatalf("expected %v, got %v", x, y)
+	}
+}
+
+func TestReadGZ(t *testing.T) {
+
+	x := tt{Name: "gz", N: 4, Words: []string{"c"}}
+	fn := filepath.Join(os.TempDir(), "write-gz", "y.json.gz")
+	e := WriteJSONFile(fn, x)
+	if e != nil {
+		t.Fatal(e)
+	}
+	var y tt
+	e = ReadJSONFile(fn, &y)
+	if e != nil {
+		t.Fatal(e)
+	}
+	if !y.equal(x) {
+		t.Fatalf("expected %v, got %v", x, y)
+	}
+
+	// Detected by content.
+	renamed := filepath.Join(os.TempDir(), "write-gz", "y.json")
+	e = os.Rename(fn, renamed)
+	if e != nil {
+		t.Fatal(e)
+	}
+	var z tt
+	e = ReadJSONFile(renamed, &z)
+	if e != nil {
+		t.Fatal(e)
+	}
+	if !z.equal(x) {
+		t.Fatalf("expected %v, got %v", x, z)
+	}
+}
+
+func TestWriteAll(t *testing.T) {
+
+	objs := []tt{}
+	for i := 0; i < 5; i++ {
+		objs = append(objs, tt{Name: "all", N: i, Words: []string{fmt.Sprintf("numero %d", i)}})
+	}
+	dir := filepath.Join(os.TempDir(), "writeall", "sub")
+	os.RemoveAll(dir)
+	for _, fn := range []string{filepath.Join(dir, "all.json"), filepath.Join(dir, "all.json.gz")} {
+		e := WriteAll(fn, objs, ".json")
+		if e != nil {
+			t.Fatal(e)
+		}
+		js, err := NewJSONStreamer(fn)
+		if err != nil {
+			t.Fatal(err)
+		}
+		i := 0
+		for ; ; i++ {
+			var o tt
+			e := js.Next(&o)
+			if e == Done {
+				break
+			}
+			if e != nil {
+				t.Fatal(e)
+			}
+			if !objs[i].equal(o) {
+				t.Fatalf("mismatch, expected %v, got %v", objs[i], o)
+			}
+		}
+		if i != len(objs) {
+			t.Fatalf("expected %d objects, got %d", len(objs), i)
+		}
+		js.Close()
+	}
+
+	e := WriteAll(filepath.Join(dir, "all.txt"), objs, ".json")
+	if e == nil {
+		t.Fatal("expected error for disallowed extension")
+	}
+}
+
+func TestWriterLevel(t *testing.T) {
+
+	dir := filepath.Join(os.TempDir(), "level")
+	sizes := map[int]int64{}
+	for _, level := range []int{gzip.BestSpeed, gzip.BestCompression} {
+		fn := filepath.Join(dir, fmt.Sprintf("level-%d.json.gz", level))
+		w, err := NewWriterLevel(fn, level)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i := 0; i < 2000; i++ {
+			x := tt{Name: fmt.Sprintf("record %d", i%17), N: i, Words: []string{"alpha", "beta", fmt.Sprintf("numero %d", i%101)}}
+			e := w.Write(&x)
+			if e != nil {
+				t.Fatal(e)
+			}
+		}
+		e := w.Close()
+		if e != nil {
+			t.Fatal(e)
+		}
+		fi, err := os.Stat(fn)
+		if err != nil {
+			t.Fatal(err)
+		}
+		sizes[level] = fi.Size()
+		t.Logf("level %d: %d bytes", level, fi.Size())
+	}
+	if sizes[gzip.BestCompression] >= sizes[gzip.BestSpeed] {
+		t.Fatalf("expected best compression (%d bytes) to be smaller than best speed (%d bytes)",
+			sizes[gzip.BestCompression], sizes[gzip.BestSpeed])
+	}
+
+	_, err := NewWriterLevel(filepath.Join(dir, "bad.json.gz"), 42)
+	if err == nil {
+		t.Fatal("expected error for invalid level")
+	}
+}
+
+func TestWriterFlush(t *testing.T) {
+
+	fn := filepath.Join(os.TempDir(), "flush", "flush.json.gz")
+	w, err := NewWriter(fn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 10; i++ {
+		x := tt{Name: "flush", N: i}
+		e := w.Write(&x)
+		if e != nil {
+			t.Fatal(e)
+		}
+	}
+	e := w.Sync()
+	if e != nil {
+		t.Fatal(e)
+	}
+
+	// Read back the flushed records while the writer is still open.
+	f, err := os.Open(fn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	zr, err := gzip.NewReader(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dec := json.NewDecoder(zr)
+	for i := 0; i < 10; i++ {
+		var o tt
+		e := dec.Decode(&o)
+		if e != nil {
+			t.Fatal(e)
+		}
+		if o.N != i {
+			t.Fatalf("expected %d, got %d", i, o.N)
+		}
+	}
+	f.Close()
+
+	x := tt{Name: "flush", N: 10}
+	e = w.Write(&x)
+	if e != nil {
+		t.Fatal(e)
+	}
+	e = w.Close()
+	if e != nil {
+		t.Fatal(e)
+	}
+}
+
+func TestFlushEvery(t *testing.T) {
+
+	fn := filepath.Join(os.TempDir(), "flush", "every.json.gz")
+	w, err := NewWriter(fn, WithFlushEvery(10))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+	for i := 0; i < 25; i++ {
+		e := w.Write(&tt{Name: "flush", N: i})
+		if e != nil {
+			t.Fatal(e)
+		}
+	}
+
+	// The first 20 objects can be read from the unfinished file.
+	f, err := os.Open(fn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	zr, err := gzip.NewReader(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dec := json.NewDecoder(zr)
+	n := 0
+	for ; ; n++ {
+		var o tt
+		if dec.Decode(&o) != nil {
+			break
+		}
+		if o.N != n {
+			t.Fatalf("expected %d, got %d", n, o.N)
+		}
+	}
+	if n != 20 {
+		t.Fatalf("expected 20 recoverable objects, got %d", n)
+	}
+}
+
+func TestNotExist(t *testing.T) {
+
+	base := filepath.Join(os.TempDir(), "notexist")
+	os.RemoveAll(base)
+	for _, fn := range []string{base, filepath.Join(base, "a.json"), filepath.Join(base, "a.list"),
+		filepath.Join(base, "a.zip"), filepath.Join(base, "a.tar.gz")} {
+		_, err := FileStreamer(fn)
+		if !errors.Is(err, os.ErrNotExist) {
+			t.Fatalf("%s: expected not exist error, got %v", fn, err)
+		}
+	}
+
+	// An empty list is an empty stream.
+	e := os.MkdirAll(base, 0777)
+	if e != nil {
+		t.Fatal(e)
+	}
+	fn := filepath.Join(base, "empty.list")
+	e = os.WriteFile(fn, []byte("\n\n"), 0644)
+	if e != nil {
+		t.Fatal(e)
+	}
+	js, err := NewJSONStreamer(fn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var o tt
+	e = js.Next(&o)
+	if e != Done {
+		t.Fatalf("expected Done, got %v", e)
+	}
+	js.Close()
+}
+
+func TestReadJSONParallel(t *testing.T) {
+
+	dir := filepath.Join(os.TempDir(), "parallel")
+	os.RemoveAll(dir)
+	for k := 0; k < 8; k++ {
+		objs := []tt{}
+		for i := 0; i < 10; i++ {
+			objs = append(objs, tt{Name: "parallel", N: k*10 + i})
+		}
+		e := WriteAll(filepath.Join(dir, fmt.Sprintf("testfile-%d.json", k)), objs)
+		if e != nil {
+			t.Fatal(e)
+		}
+	}
+
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	objCh := make(chan interface{})
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- ReadJSONParallel(dir, tt{}, objCh, 3, WithLogger(logger))
+	}()
+	seen := map[int]bool{}
+	for v := range objCh {
+		o := v.(*tt)
+		seen[o.N] = true
+	}
+	e := <-errCh
+	if e != nil {
+		t.Fatal(e)
+	}
+	if len(seen) != 80 {
+		t.Fatalf("expected 80 objects, got %d", len(seen))
+	}
+	if !strings.Contains(buf.String(), "starting workers") {
+		t.Fatalf("expected log output, got %q", buf.String())
+	}
+
+	// Corrupt file.
+	e = os.WriteFile(filepath.Join(dir, "testfile-bad.json"), []byte(`{"N":1}{"N":`), 0644)
+	if e != nil {
+		t.Fatal(e)
+	}
+	objCh = make(chan interface{})
+	go func() {
+		errCh <- ReadJSONParallel(dir, tt{}, objCh, 3)
+	}()
+	for range objCh {
+	}
+	e = <-errCh
+	if e == nil {
+		t.Fatal("expected error for corrupt file")
+	}
+	os.Remove(filepath.Join(dir, "testfile-bad.json"))
+}
+
+func TestProgress(t *testing.T) {
+
+	dir := filepath.Join(os.TempDir(), "progress")
+	os.RemoveAll(dir)
+	for k := 0; k < 3; k++ {
+		e := WriteAll(filepath.Join(dir, fmt.Sprintf("testfile-%d.json", k)), []tt{{N: k}, {N: k}})
+		if e != nil {
+			t.Fatal(e)
+		}
+	}
+	var calls []string
+	progress := func(done, total int, path string) {
+		calls = append(calls, fmt.Sprintf("%d/%d %s", done, total, filepath.Base(path)))
+	}
+	js, err := NewJSONStreamer(dir, WithProgress(progress))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for {
+		var o tt
+		e := js.Next(&o)
+		if e == Done {
+			break
+		}
+		if e != nil {
+			t.Fatal(e)
+		}
+	}
+	js.Close()
+	expected := []string{"0/3 testfile-0.json", "1/3 testfile-1.json", "2/3 testfile-2.json", "3/3 ."}
+	if strings.Join(calls, ",") != strings.Join(expected, ",") {
+		t.Fatalf("expected %v, got %v", expected, calls)
+	}
+}
+
+func TestReadJSONParallelCancel(t *testing.T) {
+
+	dir := filepath.Join(os.TempDir(), "parallelcancel")
+	os.RemoveAll(dir)
+	for k := 0; k < 20; k++ {
+		objs := make([]tt, 100)
+		e := WriteAll(filepath.Join(dir, fmt.Sprintf("testfile-%d.json", k)), objs)
+		if e != nil {
+			t.Fatal(e)
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	objCh := make(chan interface{})
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- ReadJSONParallelContext(ctx, dir, tt{}, objCh, 4)
+	}()
+	n := 0
+	for range objCh {
+		n++
+		if n == 10 {
+			cancel()
+		}
+	}
+	e := <-errCh
+	if e != context.Canceled {
+		t.Fatalf("expected context.Canceled, got %v", e)
+	}
+	if n >= 2000 {
+		t.Fatalf("expected early stop, read %d objects", n)
+	}
+}
+
+func TestSkipLimit(t *testing.T) {
+
+	dir := filepath.Join(os.TempDir(), "skiplimit")
+	os.RemoveAll(dir)
+	for k := 0; k < 3; k++ {
+		objs := []tt{}
+		for i := 0; i < 10; i++ {
+			objs = append(objs, tt{N: k*10 + i})
+		}
+		e := WriteAll(filepath.Join(dir, fmt.Sprintf("testfile-%d.json", k)), objs)
+		if e != nil {
+			t.Fatal(e)
+		}
+	}
+
+	read := func(opts ...Option) []int {
+		js, err := NewJSONStreamer(dir, opts...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer js.Close()
+		var ns []int
+		for {
+			var o tt
+			e := js.Next(&o)
+			if e == Done {
+				break
+			}
+			if e != nil {
+				t.Fatal(e)
+			}
+			ns = append(ns, o.N)
+		}
+		return ns
+	}
+
+	ns := read(WithLimit(5))
+	if fmt.Sprint(ns) != "[0 1 2 3 4]" {
+		t.Fatalf("unexpected %v", ns)
+	}
+	ns = read(WithLimit(50))
+	if len(ns) != 30 {
+		t.Fatalf("expected 30 objects, got %d", len(ns))
+	}
+	ns = read(WithSkip(8), WithLimit(4))
+	if fmt.Sprint(ns) != "[8 9 10 11]" {
+		t.Fatalf("unexpected %v", ns)
+	}
+	ns = read(WithSkip(28))
+	if fmt.Sprint(ns) != "[28 29]" {
+		t.Fatalf("unexpected %v", ns)
+	}
+	ns = read(WithSkip(100))
+	if len(ns) != 0 {
+		t.Fatalf("unexpected %v", ns)
+	}
+}
+
+func TestFileMode(t *testing.T) {
+
+	base := filepath.Join(os.TempDir(), "filemode")
+	os.RemoveAll(base)
+	dir := filepath.Join(base, "sub")
+	fn := filepath.Join(dir, "mode.json")
+	w, err := NewWriter(fn, WithFileMode(0600), WithDirMode(0700))
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.Write(&tt{Name: "a"})
+	e := w.Close()
+	if e != nil {
+		t.Fatal(e)
+	}
+	fn2 := filepath.Join(base, "other", "mode.json")
+	e = WriteJSONFile(fn2, &tt{Name: "b"}, WithFileMode(0600), WithDirMode(0700))
+	if e != nil {
+		t.Fatal(e)
+	}
+
+	for _, p := range []string{fn, fn2} {
+		fi, err := os.Stat(p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if fi.Mode().Perm() != 0600 {
+			t.Fatalf("expected file mode 0600, got %v", fi.Mode().Perm())
+		}
+		di, err := os.Stat(filepath.Dir(p))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if di.Mode().Perm() != 0700 {
+			t.Fatalf("expected dir mode 0700, got %v", di.Mode().Perm())
+		}
+	}
+}
+
+type benchObj struct {
+	Name  string
+	N     int
+	Words []string
+}
+
+func (b *benchObj) Reset() {
+	b.Name = ""
+	b.N = 0
+	b.Words = b.Words[:0]
+}
+
+func TestResetter(t *testing.T) {
+
+	fn := filepath.Join(os.TempDir(), "resetter", "r.json")
+	e := WriteAll(fn, []tt{{Name: "a", Words: []string{"x", "y"}}, {N: 2}})
+	if e != nil {
+		t.Fatal(e)
+	}
+	js, err := NewJSONStreamer(fn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer js.Close()
+	var o benchObj
+	js.Next(&o)
+	if o.Name != "a" || len(o.Words) != 2 {
+		t.Fatalf("unexpected object %v", o)
+	}
+	js.Next(&o)
+	if o.Name != "" || o.N != 2 || len(o.Words) != 0 {
+		t.Fatalf("object was not reset: %v", o)
+	}
+}
+
+func benchmarkStreamer(b *testing.B, reuse bool) {
+
+	fn := filepath.Join(os.TempDir(), "benchstreamer", "bench.json")
+	objs := make([]tt, 10000)
+	for i := range objs {
+		objs[i] = tt{Name: "bench", N: i, Words: []string{"a", "b", "c"}}
+	}
+	e := WriteAll(fn, objs)
+	if e != nil {
+		b.Fatal(e)
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		js, err := NewJSONStreamer(fn)
+		if err != nil {
+			b.Fatal(err)
+		}
+		var o benchObj
+		for {
+			if !reuse {
+				o = benchObj{}
+			}
+			e := js.Next(&o)
+			if e == Done {
+				break
+			}
+			if e != nil {
+				b.Fatal(e)
+			}
+		}
+		js.Close()
+	}
+}
+
+func BenchmarkStreamerNew(b *testing.B)   { benchmarkStreamer(b, false) }
+func BenchmarkStreamerReuse(b *testing.B) { benchmarkStreamer(b, true) }
+
+func writeRecycleFiles(tb testing.TB, name string, numFiles, numObjs int) string {
+	dir := filepath.Join(os.TempDir(), name)
+	os.RemoveAll(dir)
+	for k := 0; k < numFiles; k++ {
+		objs := make([]tt, numObjs)
+		for i := range objs {
+			n := k*numObjs + i
+			objs[i] = tt{N: n}
+			if n%2 == 0 {
+				objs[i].Name = "even"
+				objs[i].Words = []string{fmt.Sprint(n)}
+			}
+		}
+		e := WriteAll(filepath.Join(dir, fmt.Sprintf("testfile-%d.json", k)), objs)
+		if e != nil {
+			tb.Fatal(e)
+		}
+	}
+	return dir
+}
+
+func TestRecycle(t *testing.T) {
+
+	dir := writeRecycleFiles(t, "recycle", 4, 50)
+	for _, obj := range []interface{}{tt{}, benchObj{}} {
+		objCh := make(chan interface{})
+		errCh := make(chan error, 1)
+		go func() {
+			errCh <- ReadJSONParallel(dir, obj, objCh, 3)
+		}()
+		seen := map[int]bool{}
+		for v := range objCh {
+			var n int
+			var name string
+			var words []string
+			switch o := v.(type) {
+			case *tt:
+				n, name, words = o.N, o.Name, o.Words
+			case *benchObj:
+				n, name, words = o.N, o.Name, o.Words
+			}
+			even := n%2 == 0
+			if even != (name == "even") || even != (len(words) == 1) {
+				t.Fatalf("object %d has stale fields: %v", n, v)
+			}
+			if even && words[0] != fmt.Sprint(n) {
+				t.Fatalf("object %d has stale words: %v", n, words)
+			}
+			seen[n] = true
+			Recycle(v)
+		}
+		e := <-errCh
+		if e != nil {
+			t.Fatal(e)
+		}
+		if len(seen) != 200 {
+			t.Fatalf("expected 200 objects, got %d", len(seen))
+		}
+	}
+}
+
+func benchmarkReadJSONParallel(b *testing.B, recycle bool) {
+
+	dir := writeRecycleFiles(b, "benchparallel", 4, 10000)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		objCh := make(chan interface{}, 100)
+		errCh := make(chan error, 1)
+		go func() {
+			errCh <- ReadJSONParallel(dir, benchObj{}, objCh, 4)
+		}()
+		for v := range objCh {
+			if recycle {
+				Recycle(v)
+			}
+		}
+		e := <-errCh
+		if e != nil {
+			b.Fatal(e)
+		}
+	}
+}
+
+func BenchmarkReadJSONParallel(b *testing.B)        { benchmarkReadJSONParallel(b, false) }
+func BenchmarkReadJSONParallelRecycle(b *testing.B) { benchmarkReadJSONParallel(b, true) }
+
+func TestFileStreamerN(t *testing.T) {
+
+	dir := filepath.Join(os.TempDir(), "streamern")
+	os.RemoveAll(dir)
+	for k := 0; k < 3; k++ {
+		e := WriteAll(filepath.Join(dir, fmt.Sprintf("testfile-%d.json", k)), []tt{{N: k}})
+		if e != nil {
+			t.Fatal(e)
+		}
+	}
+	rc, n, err := FileStreamerN(dir, ".json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	rc.Close()
+	if n != 3 {
+		t.Fatalf("expected 3 files, got %d", n)
+	}
+	rc, n, err = FileStreamerN(dir, ".txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	rc.Close()
+	if n != 0 {
+		t.Fatalf("expected 0 files, got %d", n)
+	}
+}
+
+func TestHooks(t *testing.T) {
+
+	fn := filepath.Join(os.TempDir(), "hooks", "h.json")
+	e := os.MkdirAll(filepath.Dir(fn), 0777)
+	if e != nil {
+		t.Fatal(e)
+	}
+	e = os.WriteFile(fn, []byte(`{"nombre":"a","N":1}{"Name":"b","N":-2}{"Name":"c","N":3}`), 0644)
+	if e != nil {
+		t.Fatal(e)
+	}
+	fix := WithRawHook(func(raw []byte) ([]byte, error) {
+		return bytes.ReplaceAll(raw, []byte(`"nombre"`), []byte(`"Name"`)), nil
+	})
+	check := WithValueHook(func(v interface{}) error {
+		o := v.(*tt)
+		if o.N < 0 {
+			return fmt.Errorf("negative N: %w", ErrInvalid)
+		}
+		o.Name = strings.ToUpper(o.Name)
+		return nil
+	})
+
+	js, err := NewJSONStreamer(fn, fix, check, WithSkipInvalid())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for {
+		var o tt
+		e := js.Next(&o)
+		if e == Done {
+			break
+		}
+		if e != nil {
+			t.Fatal(e)
+		}
+		names = append(names, o.Name)
+	}
+	js.Close()
+	if strings.Join(names, ",") != "A,C" {
+		t.Fatalf("unexpected objects: %v", names)
+	}
+
+	js, err = NewJSONStreamer(fn, fix, check)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer js.Close()
+	var o tt
+	js.Next(&o)
+	e = js.Next(&o)
+	if !errors.Is(e, ErrInvalid) {
+		t.Fatalf("expected invalid error, got %v", e)
+	}
+}
+
+func benchmarkGZIPReader(b *testing.B, size int) {
+
+	fn := filepath.Join(os.TempDir(), "benchgzip", "bench.json.gz")
+	if _, err := os.Stat(fn); err != nil {
+		objs := make([]tt, 200000)
+		for i := range objs {
+			objs[i] = tt{Name: fmt.Sprintf("name-%d", i), N: i, Words: []string{"a", fmt.Sprint(i * 7)}}
+		}
+		e := WriteAll(fn, objs)
+		if e != nil {
+			b.Fatal(e)
+		}
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		f, err := os.Open(fn)
+		if err != nil {
+			b.Fatal(err)
+		}
+		gr, err := NewGZIPReaderSize(f, size)
+		if err != nil {
+			b.Fatal(err)
+		}
+		n, err := io.Copy(io.Discard, gr)
+		if err != nil {
+			b.Fatal(err)
+		}
+		gr.Close()
+		b.SetBytes(n)
+	}
+}
+
+func BenchmarkGZIPReader4K(b *testing.B)  { benchmarkGZIPReader(b, 4096) }
+func BenchmarkGZIPReader64K(b *testing.B) { benchmarkGZIPReader(b, DefaultGZIPBufferSize) }
+func BenchmarkGZIPReader1M(b *testing.B)  { benchmarkGZIPReader(b, 1<<20) }
+
+func TestFileStreamerWriteTo(t *testing.T) {
+
+	dir := filepath.Join(os.TempDir(), "writeto")
+	os.RemoveAll(dir)
+	var expected bytes.Buffer
+	for k := 0; k < 3; k++ {
+		fn := filepath.Join(dir, fmt.Sprintf("testfile-%d.json", k))
+		if k == 1 {
+			fn += ".gz"
+		}
+		objs := []tt{{Name: "writeto", N: k}, {N: k + 10}}
+		e := WriteAll(fn, objs)
+		if e != nil {
+			t.Fatal(e)
+		}
+		for _, o := range objs {
+			WriteJSON(&expected, o)
+		}
+	}
+	rc, err := FileStreamer(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rc.Close()
+	if _, ok := rc.(io.WriterTo); !ok {
+		t.Fatal("expected an io.WriterTo")
+	}
+	var buf bytes.Buffer
+	n, err := io.Copy(&buf, rc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != int64(buf.Len()) || buf.String() != expected.String() {
+		t.Fatalf("expected %q, got %q (%d bytes)", expected.String(), buf.String(), n)
+	}
+}
+
+func TestJSONStreamerExt(t *testing.T) {
+
+	dir := filepath.Join(os.TempDir(), "streamerext")
+	os.RemoveAll(dir)
+	for i, fn := range []string{"a.ndjson", "b.ndjson.gz", "c.json"} {
+		e := WriteAll(filepath.Join(dir, fn), []tt{{N: i}})
+		if e != nil {
+			t.Fatal(e)
+		}
+	}
+	js, err := NewJSONStreamerExt(dir, ".ndjson")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer js.Close()
+	var ns []int
+	for {
+		var o tt
+		e := js.Next(&o)
+		if e == Done {
+			break
+		}
+		if e != nil {
+			t.Fatal(e)
+		}
+		ns = append(ns, o.N)
+	}
+	if len(ns) != 2 || ns[0] != 0 || ns[1] != 1 {
+		t.Fatalf("unexpected objects: %v", ns)
+	}
+
+	// With options.
+	js, err = NewJSONStreamerExtOpts(dir, []string{".ndjson", ".json"}, WithSkip(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer js.Close()
+	ns = nil
+	for {
+		var o tt
+		e := js.Next(&o)
+		if e == Done {
+			break
+		}
+		if e != nil {
+			t.Fatal(e)
+		}
+		ns = append(ns, o.N)
+	}
+	if len(ns) != 2 || ns[0] != 1 || ns[1] != 2 {
+		t.Fatalf("unexpected objects: %v", ns)
+	}
+}
+
+func TestSymlinkPath(t *testing.T) {
+
+	base := filepath.Join(os.TempDir(), "symlinkpath")
+	os.RemoveAll(base)
+	dir := filepath.Join(base, "real")
+	for k := 0; k < 2; k++ {
+		e := WriteAll(filepath.Join(dir, fmt.Sprintf("testfile-%d.json", k)), []tt{{N: k}})
+		if e != nil {
+			t.Fatal(e)
+		}
+	}
+	link := filepath.Join(base, "link")
+	e := os.Symlink(dir, link)
+	if e != nil {
+		t.Skip("symbolic links not supported:", e)
+	}
+
+	var names []string
+	progress := func(done, total int, path string) {
+		names = append(names, path)
+	}
+	js, err := NewJSONStreamer(link, WithProgress(progress))
+	if err != nil {
+		t.Fatal(err)
+	}
+	n := 0
+	for {
+		var o tt
+		e := js.Next(&o)
+		if e == Done {
+			break
+		}
+		if e != nil {
+			t.Fatal(e)
+		}
+		n++
+	}
+	js.Close()
+	if n != 2 {
+		t.Fatalf("expected 2 objects, got %d", n)
+	}
+	if names[0] != filepath.Join(link, "testfile-0.json") {
+		t.Fatalf("expected path under the link, got %s", names[0])
+	}
+
+	_, err = NewJSONStreamer(link, WithRejectSymlinks())
+	if !errors.Is(err, ErrSymlink) {
+		t.Fatalf("expected ErrSymlink, got %v", err)
+	}
+	js, err = NewJSONStreamer(dir, WithRejectSymlinks())
+	if err != nil {
+		t.Fatal(err)
+	}
+	js.Close()
+}
+
+func TestSkipMissing(t *testing.T) {
+
+	dir := filepath.Join(os.TempDir(), "skipmissing")
+	os.RemoveAll(dir)
+	var list []string
+	for k := 0; k < 3; k++ {
+		fn := filepath.Join(dir, fmt.Sprintf("testfile-%d.json", k))
+		list = append(list, fn)
+		if k == 1 {
+			continue
+		}
+		e := WriteAll(fn, []tt{{N: k}})
+		if e != nil {
+			t.Fatal(e)
+		}
+	}
+	listFN := filepath.Join(dir, "files.list")
+	e := os.WriteFile(listFN, []byte(strings.Join(list, "\n")), 0644)
+	if e != nil {
+		t.Fatal(e)
+	}
+	read := func(opts ...Option) (*JSONStreamer, []int, error) {
+		js, err := NewJSONStreamer(listFN, opts...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer js.Close()
+		var ns []int
+		for {
+			var o tt
+			e := js.Next(&o)
+			if e == Done {
+				return js, ns, nil
+			}
+			if e != nil {
+				return js, ns, e
+			}
+			ns = append(ns, o.N)
+		}
+	}
+	_, _, e = read()
+	if !errors.Is(e, os.ErrNotExist) {
+		t.Fatalf("expected not exist error, got %v", e)
+	}
+	js, ns, e := read(WithSkipMissing())
+	if e != nil {
+		t.Fatal(e)
+	}
+	if len(ns) != 2 || ns[0] != 0 || ns[1] != 2 {
+		t.Fatalf("unexpected objects: %v", ns)
+	}
+	if m := js.Missing(); len(m) != 1 || m[0] != list[1] {
+		t.Fatalf("unexpected missing files: %v", m)
+	}
+}
+
+func TestReadJSONStrictOne(t *testing.T) {
+
+	var o tt
+	e := ReadJSONStrictOne(strings.NewReader(" {\"N\":1}\n\n"), &o)
+	if e != nil || o.N != 1 {
+		t.Fatalf("unexpected result %v, %v", o, e)
+	}
+	for _, data := range []string{"", "  \n", `{"N":1}{"N":2}`, `{"N":1} x`, `{"N":1}]`} {
+		e := ReadJSONStrictOne(strings.NewReader(data), &o)
+		if e == nil {
+			t.Fatalf("%q: expected error", data)
+		}
+	}
+}
+
+func TestUseAfterClose(t *testing.T) {
+
+	for _, fn := range []string{"c.json", "c.json.gz"} {
+		fn = filepath.Join(os.TempDir(), "afterclose", fn)
+		w, err := NewWriter(fn)
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Write(&tt{N: 1})
+		e := w.Close()
+		if e != nil {
+			t.Fatal(e)
+		}
+		if e := w.Write(&tt{N: 2}); e != ErrClosedWriter {
+			t.Fatalf("expected ErrClosedWriter, got %v", e)
+		}
+		if e := w.Flush(); e != ErrClosedWriter {
+			t.Fatalf("expected ErrClosedWriter, got %v", e)
+		}
+		if e := w.Close(); e != nil {
+			t.Fatalf("expected second Close to be a no-op, got %v", e)
+		}
+
+		js, err := NewJSONStreamer(fn)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var o tt
+		js.Next(&o)
+		js.Close()
+		if e := js.Next(&o); e != ErrClosedStreamer {
+			t.Fatalf("expected ErrClosedStreamer, got %v", e)
+		}
+		if e := js.Close(); e != nil {
+			t.Fatalf("expected second Close to be a no-op, got %v", e)
+		}
+	}
+}
+
+func TestFileError(t *testing.T) {
+
+	dir := filepath.Join(os.TempDir(), "fileerror")
+	os.RemoveAll(dir)
+	e := WriteAll(filepath.Join(dir, "a.json"), []tt{{N: 1}})
+	if e != nil {
+		t.Fatal(e)
+	}
+	bad := filepath.Join(dir, "b.json")
+	e = os.WriteFile(bad, []byte(`{"N":2}{"N":`), 0644)
+	if e != nil {
+		t.Fatal(e)
+	}
+	badGZ := filepath.Join(dir, "c.json.gz")
+	e = os.WriteFile(badGZ, []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xffgarbage"), 0644)
+	if e != nil {
+		t.Fatal(e)
+	}
+
+	check := func(err error, path string) {
+		t.Helper()
+		var fe *FileError
+		if !errors.As(err, &fe) {
+			t.Fatalf("expected a FileError, got %v", err)
+		}
+		if fe.Path != path {
+			t.Fatalf("expected path %s, got %s", path, fe.Path)
+		}
+		if !strings.Contains(err.Error(), filepath.Base(path)) {
+			t.Fatalf("path missing from error message %q", err.Error())
+		}
+	}
+
+	js, err := NewJSONStreamer(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for {
+		var o tt
+		e = js.Next(&o)
+		if e != nil {
+			break
+		}
+	}
+	js.Close()
+	check(e, bad)
+
+	os.Remove(bad)
+	rc, err := FileStreamer(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, e = io.ReadAll(rc)
+	rc.Close()
+	check(e, badGZ)
+
+	_, e = CollectParallel[tt](dir, 2)
+	check(e, badGZ)
+
+	e = ReadJSONParallel(dir, tt{}, make(chan interface{}, 10), 1)
+	check(e, badGZ)
+}
+
+func TestExpandPaths(t *testing.T) {
+
+	dir := filepath.Join(os.TempDir(), "expand")
+	os.RemoveAll(dir)
+	for _, fn := range []string{"b.json", "a.json.gz", "c.txt", ".d.json", "sub/e.json"} {
+		e := WriteAll(filepath.Join(dir, fn), []tt{{N: 1}})
+		if e != nil {
+			t.Fatal(e)
+		}
+	}
+	paths, err := ExpandPaths(dir, ".json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{filepath.Join(dir, "a.json.gz"), filepath.Join(dir, "b.json"), filepath.Join(dir, "sub", "e.json")}
+	if strings.Join(paths, ",") != strings.Join(expected, ",") {
+		t.Fatalf("expected %v, got %v", expected, paths)
+	}
+	all, err := ExpandPaths(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(all) != 4 {
+		t.Fatalf("expected 4 files, got %v", all)
+	}
+}
+
+func TestNextWithSource(t *testing.T) {
+
+	dir := filepath.Join(os.TempDir(), "source")
+	os.RemoveAll(dir)
+	data := []tt{{Name: "a", N: 1}, {Name: "bb", N: 22}}
+	files := []string{filepath.Join(dir, "f1.json"), filepath.Join(dir, "f2.json.gz")}
+	for _, fn := range files {
+		e := WriteAll(fn, data)
+		if e != nil {
+			t.Fatal(e)
+		}
+	}
+	var offsets []int64
+	var end int64
+	for _, v := range data {
+		b, _ := json.Marshal(v)
+		offsets = append(offsets, end+int64(len(b)))
+		end += int64(len(b)) + 1
+	}
+
+	js, err := NewJSONStreamer(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer js.Close()
+	for i := 0; ; i++ {
+		var v tt
+		source, offset, err := js.NextWithSource(&v)
+		if err == Done {
+			if i != 4 {
+				t.Fatalf("expected 4 objects, got %d", i)
+			}
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		if source != files[i/2] {
+			t.Fatalf("object %d: expected source %s, got %s", i, files[i/2], source)
+		}
+		if offset != offsets[i%2] {
+			t.Fatalf("object %d: expected offset %d, got %d", i, offsets[i%2], offset)
+		}
+	}
+}
+
+func TestFullReads(t *testing.T) {
+
+	dir := filepath.Join(os.TempDir(), "fullreads")
+	os.RemoveAll(dir)
+	var expected bytes.Buffer
+	for i := 0; i < 5; i++ {
+		data := []tt{{Name: fmt.Sprint("file-", i), N: i}}
+		e := WriteAll(filepath.Join(dir, fmt.Sprintf("f%d.json", i)), data)
+		if e != nil {
+			t.Fatal(e)
+		}
+		b, _ := json.Marshal(data[0])
+		expected.Write(b)
+		expected.WriteByte('\n')
+	}
+
+	for _, size := range []int{1, 3, 7, 64} {
+		for _, full := range []bool{false, true} {
+			var opts []Option
+			if full {
+				opts = append(opts, WithFullReads())
+			}
+			r, err := NewFileStreamer(dir, nil, opts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got bytes.Buffer
+			buf := make([]byte, size)
+			short := 0
+			for {
+				n, err := r.Read(buf)
+				got.Write(buf[:n])
+				if err == io.EOF {
+					break
+				}
+				if err != nil {
+					t.Fatal(err)
+				}
+				if n < size {
+					short++
+				}
+			}
+			r.Close()
+			if got.String() != expected.String() {
+				t.Fatalf("size %d: expected %q, got %q", size, expected.String(), got.String())
+			}
+			// Without full reads, the last read of each file may be short.
+			if full && short > 1 {
+				t.Fatalf("size %d: got %d short reads", size, short)
+			}
+			if !full && size == 64 && short < 4 {
+				t.Fatalf("size %d: expected a short read per file, got %d", size, short)
+			}
+		}
+	}
+}
+
+func TestEstimateCount(t *testing.T) {
+
+	dir := filepath.Join(os.TempDir(), "count")
+	os.RemoveAll(dir)
+	for i, fn := range []string{"a.json", "b.json.gz", "c.json"} {
+		data := make([]tt, 10*(i+1))
+		e := WriteAll(filepath.Join(dir, fn), data)
+		if e != nil {
+			t.Fatal(e)
+		}
+	}
+	for _, c := range []struct {
+		opts     []Option
+		expected int64
+	}{
+		{nil, 60},
+		{[]Option{WithSkip(15)}, 45},
+		{[]Option{WithSkip(100)}, 0},
+		{[]Option{WithLimit(20)}, 20},
+		{[]Option{WithSkip(50), WithLimit(20)}, 10},
+	} {
+		js, err := NewJSONStreamer(dir, c.opts...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var first tt
+		e := js.Next(&first)
+		if c.expected > 0 && e != nil {
+			t.Fatal(e)
+		}
+		n, err := js.EstimateCount()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if n != c.expected {
+			t.Fatalf("expected %d, got %d", c.expected, n)
+		}
+		// The stream is not consumed.
+		count := int64(1)
+		for {
+			var x tt
+			e := js.Next(&x)
+			if e == Done {
+				break
+			}
+			if e != nil {
+				t.Fatal(e)
+			}
+			count++
+		}
+		if c.expected > 0 && count != c.expected {
+			t.Fatalf("expected to read %d objects, got %d", c.expected, count)
+		}
+		js.Close()
+	}
+}
+
+func TestEmptyFile(t *testing.T) {
+
+	dir := filepath.Join(os.TempDir(), "empty")
+	os.RemoveAll(dir)
+	e := os.MkdirAll(dir, 0777)
+	if e != nil {
+		t.Fatal(e)
+	}
+	fn := filepath.Join(dir, "a.json")
+	for i, content := range []string{"", " \n\t\n"} {
+		e = os.WriteFile(fn, []byte(content), 0644)
+		if e != nil {
+			t.Fatal(e)
+		}
+		e = WriteAll(filepath.Join(dir, "b.json.gz"), []tt{})
+		if e != nil {
+			t.Fatal(e)
+		}
+
+		y := tt{N: 7}
+		e = ReadJSON(strings.NewReader(content), &y)
+		if e != nil || y.N != 7 {
+			t.Fatalf("case %d: ReadJSON: expected unmodified object and no error, got %v, %v", i, y, e)
+		}
+		e = ReadJSONStrictOne(strings.NewReader(content), &y)
+		if e != io.ErrUnexpectedEOF {
+			t.Fatalf("case %d: ReadJSONStrictOne: expected io.ErrUnexpectedEOF, got %v", i, e)
+		}
+		e = ReadJSONFile(fn, &y)
+		if !errors.Is(e, ErrEmpty) || y.N != 7 {
+			t.Fatalf("case %d: ReadJSONFile: expected ErrEmpty, got %v", i, e)
+		}
+
+		for _, path := range []string{fn, dir} {
+			js, err := NewJSONStreamer(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			e = js.Next(&y)
+			if e != Done {
+				t.Fatalf("case %d: JSONStreamer %s: expected Done, got %v", i, path, e)
+			}
+			js.Close()
+
+			r, err := FileStreamer(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			b, err := io.ReadAll(r)
+			if err != nil || string(b) != content {
+				t.Fatalf("case %d: FileStreamer %s: got %q, %v", i, path, b, err)
+			}
+			r.Close()
+
+			objs, err := CollectParallel[tt](path, 2)
+			if err != nil || len(objs) != 0 {
+				t.Fatalf("case %d: CollectParallel %s: got %v, %v", i, path, objs, err)
+			}
+		}
+	}
+}
+
+func TestProgressEvery(t *testing.T) {
+
+	dir := filepath.Join(os.TempDir(), "heartbeat")
+	os.RemoveAll(dir)
+	for _, fn := range []string{"a.json", "b.json.gz"} {
+		e := WriteAll(filepath.Join(dir, fn), make([]tt, 25))
+		if e != nil {
+			t.Fatal(e)
+		}
+	}
+	var counts []int64
+	js, err := NewJSONStreamer(dir, WithProgressEvery(10, func(n int64) { counts = append(counts, n) }))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer js.Close()
+
+	// Counting does not report progress.
+	n, err := js.EstimateCount()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 50 || len(counts) != 0 {
+		t.Fatalf("expected 50 objects and no progress calls, got %d and %v", n, counts)
+	}
+	for {
+		var x tt
+		e := js.Next(&x)
+		if e == Done {
+			break
+		}
+		if e != nil {
+			t.Fatal(e)
+		}
+	}
+	if fmt.Sprint(counts) != "[10 20 30 40 50]" {
+		t.Fatalf("unexpected counts %v", counts)
+	}
+}
+
+type closeCounter struct {
+	io.Reader
+	closed int
+}
+
+func (c *closeCounter) Close() error {
+	c.closed++
+	return nil
+}
+
+func TestJSONStreamerReadCloser(t *testing.T) {
+
+	rc := &closeCounter{Reader: strings.NewReader("{\"N\":1}\n{\"N\":2} {\"N\":3}")}
+	js := NewJSONStreamerReadCloser(rc)
+	for i := 1; i <= 3; i++ {
+		var x tt
+		e := js.Next(&x)
+		if e != nil {
+			t.Fatal(e)
+		}
+		if x.N != i {
+			t.Fatalf("expected N=%d, got %d", i, x.N)
+		}
+	}
+	var x tt
+	if e := js.Next(&x); e != Done {
+		t.Fatalf("expected Done, got %v", e)
+	}
+	if _, e := js.EstimateCount(); e == nil {
+		t.Fatal("expected error from EstimateCount")
+	}
+	js.Close()
+	js.Close()
+	if rc.closed != 1 {
+		t.Fatalf("expected reader to be closed once, got %d", rc.closed)
+	}
+	if e := js.Next(&x); e != ErrClosedStreamer {
+		t.Fatalf("expected ErrClosedStreamer, got %v", e)
+	}
+}
+
+func TestMaxFiles(t *testing.T) {
+
+	dir := filepath.Join(os.TempDir(), "maxfiles")
+	os.RemoveAll(dir)
+	for i := 0; i < 5; i++ {
+		fn := filepath.Join(dir, fmt.Sprintf("f%d.json", i))
+		e := WriteAll(fn, []tt{{Name: fn, N: 1}, {Name: fn, N: 2}})
+		if e != nil {
+			t.Fatal(e)
+		}
+	}
+	js, err := NewJSONStreamer(dir, WithMaxFiles(2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer js.Close()
+	var names []string
+	for {
+		var x tt
+		e := js.Next(&x)
+		if e == Done {
+			break
+		}
+		if e != nil {
+			t.Fatal(e)
+		}
+		names = append(names, filepath.Base(x.Name))
+	}
+	if strings.Join(names, ",") != "f0.json,f0.json,f1.json,f1.json" {
+		t.Fatalf("unexpected objects from %v", names)
+	}
+
+	objs, err := CollectParallel[tt](dir, 2, WithMaxFiles(3))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(objs) != 6 {
+		t.Fatalf("expected 6 objects, got %d", len(objs))
+	}
+}
+
+func TestShuffle(t *testing.T) {
+
+	dir := filepath.Join(os.TempDir(), "shuffle")
+	os.RemoveAll(dir)
+	for i := 0; i < 20; i++ {
+		e := WriteAll(filepath.Join(dir, fmt.Sprintf("f%02d.json", i)), []tt{{N: i}})
+		if e != nil {
+			t.Fatal(e)
+		}
+	}
+	read := func(opts ...Option) []int {
+		js, err := NewJSONStreamer(dir, opts...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer js.Close()
+		var ns []int
+		for {
+			var x tt
+			e := js.Next(&x)
+			if e == Done {
+				return ns
+			}
+			if e != nil {
+				t.Fatal(e)
+			}
+			ns = append(ns, x.N)
+		}
+	}
+	a := read(WithShuffle(42))
+	b := read(WithShuffle(42))
+	c := read(WithShuffle(7))
+	sorted := read()
+	if fmt.Sprint(a) != fmt.Sprint(b) {
+		t.Fatalf("same seed, different order: %v %v", a, b)
+	}
+	if fmt.Sprint(a) == fmt.Sprint(c) || fmt.Sprint(a) == fmt.Sprint(sorted) {
+		t.Fatalf("order not shuffled: %v %v", a, c)
+	}
+	sort.Ints(a)
+	if fmt.Sprint(a) != fmt.Sprint(sorted) {
+		t.Fatalf("shuffled files differ: %v", a)
+	}
+	sample := read(WithShuffle(42), WithMaxFiles(5))
+	if fmt.Sprint(sample) != fmt.Sprint(b[:5]) {
+		t.Fatalf("expected sample %v, got %v", b[:5], sample)
+	}
+}
+
+// lockedBuffer is a buffer that can be written by a finalizer.
+type lockedBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *lockedBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *lockedBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func TestFileStreamerLeaks(t *testing.T) {
+
+	dir := filepath.Join(os.TempDir(), "leaks")
+	os.RemoveAll(dir)
+	for k := 0; k < 3; k++ {
+		fn := filepath.Join(dir, fmt.Sprintf("testfile-%d.json", k))
+		if k == 1 {
+			fn += ".gz"
+		}
+		e := WriteAll(fn, []tt{{Name: "leaks", N: k}, {N: k + 10}})
+		if e != nil {
+			t.Fatal(e)
+		}
+	}
+	openFiles := func() int {
+		fds, err := os.ReadDir("/proc/self/fd")
+		if err != nil {
+			return -1
+		}
+		return len(fds)
+	}
+
+	before := openFiles()
+	errStop := errors.New("stop")
+	buf := make([]byte, 10)
+	for i := 0; i < 500; i++ {
+		rc, err := FileStreamer(dir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = io.ReadFull(rc, buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		e := rc.Close()
+		if e != nil {
+			t.Fatal(e)
+		}
+		e = StreamFunc(dir, func(rc io.ReadCloser) error {
+			_, err := io.ReadFull(rc, buf)
+			if err != nil {
+				return err
+			}
+			return errStop
+		})
+		if e != errStop {
+			t.Fatalf("expected the error of fn, got %v", e)
+		}
+	}
+	if after := openFiles(); after > before {
+		t.Fatalf("expected %d open files, got %d", before, after)
+	}
+
+	// A reader that is not closed is closed when garbage collected.
+	var logs lockedBuffer
+	logger := slog.New(slog.NewTextHandler(&logs, nil))
+	func() {
+		rc, err := NewFileStreamer(dir, nil, WithLogger(logger))
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = io.ReadFull(rc, buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}()
+	for i := 0; i < 100 && !strings.Contains(logs.String(), "not closed"); i++ {
+		runtime.GC()
+		time.Sleep(10 * time.Millisecond)
+	}
+	if !strings.Contains(logs.String(), "file streamer was not closed") {
+		t.Fatalf("expected a warning, got %q", logs.String())
+	}
+	if after := openFiles(); after > before {
+		t.Fatalf("expected %d open files, got %d", before, after)
+	}
+}
+
+func TestNonRegularFiles(t *testing.T) {
+
+	dir := filepath.Join(os.TempDir(), "nonregular")
+	os.RemoveAll(dir)
+	e := WriteAll(filepath.Join(dir, "testfile-0.json"), []tt{{N: 0}, {N: 1}})
+	if e != nil {
+		t.Fatal(e)
+	}
+	e = os.MkdirAll(filepath.Join(dir, "sub.json"), 0777)
+	if e != nil {
+		t.Fatal(e)
+	}
+	l, err := net.Listen("unix", filepath.Join(dir, "sock.json"))
+	if err != nil {
+		t.Skip("unix sockets not supported:", err)
+	}
+	defer l.Close()
+
+	paths, err := ExpandPaths(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(paths) != 1 || paths[0] != filepath.Join(dir, "testfile-0.json") {
+		t.Fatalf("expected only the regular file, got %v", paths)
+	}
+	js, err := NewJSONStreamer(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer js.Close()
+	n := 0
+	for {
+		var o tt
+		e := js.Next(&o)
+		if e == Done {
+			break
+		}
+		if e != nil {
+			t.Fatal(e)
+		}
+		n++
+	}
+	if n != 2 {
+		t.Fatalf("expected 2 objects, got %d", n)
+	}
+
+	paths, err = extractPaths(dir, newOptions(WithNonRegularFiles()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(paths) != 2 || paths[0] != filepath.Join(dir, "sock.json") {
+		t.Fatalf("expected the socket and the regular file, got %v", paths)
+	}
+}
+
+func TestRequiredFields(t *testing.T) {
+
+	fn := filepath.Join(os.TempDir(), "required", "r.json")
+	e := os.MkdirAll(filepath.Dir(fn), 0777)
+	if e != nil {
+		t.Fatal(e)
+	}
+	e = os.WriteFile(fn, []byte(`{"Name":"a","N":1} {"Name":null,"N":2} {"N":3} [1] {"Name":"e","N":5,"Words":null}`), 0644)
+	if e != nil {
+		t.Fatal(e)
+	}
+
+	js, err := NewJSONStreamer(fn, WithRequiredFields([]string{"Name", "N"}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer js.Close()
+	var o tt
+	e = js.Next(&o)
+	if e != nil {
+		t.Fatal(e)
+	}
+	e = js.Next(&o)
+	if !errors.Is(e, ErrInvalid) || !strings.Contains(e.Error(), `"Name"`) || strings.Contains(e.Error(), `"N"`) {
+		t.Fatalf("expected ErrInvalid for Name, got %v", e)
+	}
+
+	js, err = NewJSONStreamer(fn, WithRequiredFields([]string{"Name", "N"}), WithSkipInvalid())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer js.Close()
+	var names []string
+	for {
+		var o tt
+		e := js.Next(&o)
+		if e == Done {
+			break
+		}
+		if e != nil {
+			t.Fatal(e)
+		}
+		names = append(names, o.Name)
+	}
+	if strings.Join(names, ",") != "a,e" {
+		t.Fatalf("unexpected objects: %v", names)
+	}
+}
@@ -0,0 +1,147 @@
+// Copyright (c) 2015 AKUALAB INC., All rights reserved.
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ju
+
+import (
+	"errors"
+	"fmt"
+	"io"
+)
+
+// ErrRecordTooLarge is wrapped by the error returned when a record exceeds the size set with
+// WithMaxRecordBytes.
+var ErrRecordTooLarge = errors.New("record too large")
+
+// WithMaxRecordBytes limits the size of each record read by streamers to n bytes, including the
+// whitespace that precedes it. The decoder is never given more than n bytes past the end of
+// the previous record, so a huge value in untrusted input fails with an error wrapping
+// ErrRecordTooLarge instead of exhausting memory. The rest of the file cannot be read after
+// the error. Zero means no limit.
+func WithMaxRecordBytes(n int64) Option {
+	return func(o *options) {
+		o.maxRecord = n
+	}
+}
+
+// limitRecords returns the decoder created by newDec for r, limiting the size of each record
+// if WithMaxRecordBytes is set.
+func (o *options) limitRecords(r io.Reader, newDec func(io.Reader) Decoder) Decoder {
+	if o.maxRecord <= 0 {
+		return newDec(r)
+	}
+	lr := &recordLimitReader{r: r, max: o.maxRecord}
+	return &limitDecoder{dec: newDec(lr), lr: lr}
+}
+
+// recordLimitReader stops reading when more than max bytes are read past start.
+type recordLimitReader struct {
+	r     io.Reader
+	max   int64
+	start int64 // offset of the end of the last record
+	n     int64 // bytes read
+}
+
+func (lr *recordLimitReader) Read(p []byte) (int, error) {
+	allowed := lr.start + lr.max - lr.n
+	if allowed <= 0 {
+		return 0, fmt.Errorf("%w: more than %d bytes at offset %d", ErrRecordTooLarge, lr.max, lr.start)
+	}
+	if int64(len(p)) > allowed {
+		p = p[:allowed]
+	}
+	n, err := lr.r.Read(p)
+	lr.n += int64(n)
+	return n, err
+}
+
+// limitDecoder moves the start of the limit reader to the end of each decoded record.
+type limitDecoder struct {
+	dec Decoder
+	lr  *recordLimitReader
+}
+
+func (d *limitDecoder) Decode(v interface{}) error {
+	err := d.dec.Decode(v)
+	if err == nil {
+		d.lr.start = d.InputOffset()
+		if d.lr.start < 0 {
+			// The decoder may have read ahead, so the limit is approximate.
+			d.lr.start = d.lr.n
+		}
+	}
+	return err
+}
+
+// InputOffset returns the input offset of the underlying decoder, or -1 if it is not available.
+func (d *limitDecoder) InputOffset() int64 {
+	if od, ok := d.dec.(interface{ InputOffset() int64 }); ok {
+		return od.InputOffset()
+	}
+	return -1
+}
+
+// ErrTooDeep is wrapped by the error returned when a record is nested deeper than the depth
+// set with WithMaxDepth.
+var ErrTooDeep = errors.New("json nested too deeply")
+
+// WithMaxDepth rejects the records read by streamers whose objects and arrays are nested more
+// than n levels deep; {"a":[1]} has depth 2. The input is checked before it is decoded and the
+// error wraps ErrTooDeep. The rest of the file cannot be read after the error.
+// Zero means no limit.
+func WithMaxDepth(n int) Option {
+	return func(o *options) {
+		o.maxDepth = n
+	}
+}
+
+// depthReader fails when the nesting depth of the json data exceeds max.
+type depthReader struct {
+	rc    io.ReadCloser
+	max   int
+	depth int
+	off   int64
+	inStr bool
+	esc   bool
+	err   error
+}
+
+func (d *depthReader) Read(p []byte) (int, error) {
+	if d.err != nil {
+		return 0, d.err
+	}
+	n, err := d.rc.Read(p)
+	for i, c := range p[:n] {
+		if d.inStr {
+			switch {
+			case d.esc:
+				d.esc = false
+			case c == '\\':
+				d.esc = true
+			case c == '"':
+				d.inStr = false
+			}
+			continue
+		}
+		switch c {
+		case '"':
+			d.inStr = true
+		case '{', '[':
+			d.depth++
+			if d.depth > d.max {
+				d.err = fmt.Errorf("%w: more than %d levels at offset %d", ErrTooDeep, d.max, d.off+int64(i))
+				return i, d.err
+			}
+		case '}', ']':
+			d.depth--
+		}
+	}
+	d.off += int64(n)
+	return n, err
+}
+
+func (d *depthReader) Close() error {
+	return d.rc.Close()
+}
@@ -0,0 +1,121 @@
+// Copyright (c) 2015 AKUALAB INC., All rights reserved.
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ju
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestMaxRecordBytes(t *testing.T) {
+
+	fn := filepath.Join(os.TempDir(), "limit", "big.json.gz")
+	data := make([]tt, 100)
+	for i := range data {
+		data[i] = tt{Name: "small", N: i}
+	}
+	data[50].Name = strings.Repeat("x", 100000)
+	e := WriteAll(fn, data)
+	if e != nil {
+		t.Fatal(e)
+	}
+
+	for _, opts := range [][]Option{{WithMaxRecordBytes(200)}, {WithMaxRecordBytes(200), WithRecover()}} {
+		js, err := NewJSONStreamer(fn, opts...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		n := 0
+		for {
+			var x tt
+			err = js.Next(&x)
+			if err != nil {
+				break
+			}
+			n++
+		}
+		js.Close()
+		if !errors.Is(err, ErrRecordTooLarge) {
+			t.Fatalf("expected ErrRecordTooLarge, got %v", err)
+		}
+		if n != 50 {
+			t.Fatalf("expected 50 objects before the error, got %d", n)
+		}
+	}
+
+	objs, err := CollectParallel[tt](fn, 2, WithMaxRecordBytes(200))
+	if !errors.Is(err, ErrRecordTooLarge) {
+		t.Fatalf("expected ErrRecordTooLarge, got %v, %d objects", err, len(objs))
+	}
+
+	js, err := NewJSONStreamer(fn, WithMaxRecordBytes(200000))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer js.Close()
+	for i := 0; ; i++ {
+		var x tt
+		err := js.Next(&x)
+		if err == Done {
+			if i != len(data) {
+				t.Fatalf("expected %d objects, got %d", len(data), i)
+			}
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !x.equal(data[i]) {
+			t.Fatalf("expected %v, got %v", data[i], x)
+		}
+	}
+}
+
+func TestMaxDepth(t *testing.T) {
+
+	fn := filepath.Join(os.TempDir(), "limit", "deep.json")
+	data := `{"a": [1, {"b": "[[[[{{{{"}], "c": {}}
+{"a": [[["x"]]]}
+` + strings.Repeat("[", 100000) + strings.Repeat("]", 100000) + "\n"
+	e := os.WriteFile(fn, []byte(data), 0644)
+	if e != nil {
+		t.Fatal(e)
+	}
+
+	js, err := NewJSONStreamer(fn, WithMaxDepth(3))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer js.Close()
+	var v interface{}
+	e = js.Next(&v)
+	if e != nil {
+		t.Fatal(e)
+	}
+	e = js.Next(&v)
+	if !errors.Is(e, ErrTooDeep) {
+		t.Fatalf("expected ErrTooDeep, got %v", e)
+	}
+
+	js, err = NewJSONStreamer(fn, WithMaxDepth(4))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer js.Close()
+	for i := 0; i < 2; i++ {
+		e = js.Next(&v)
+		if e != nil {
+			t.Fatal(e)
+		}
+	}
+	e = js.Next(&v)
+	if !errors.Is(e, ErrTooDeep) {
+		t.Fatalf("expected ErrTooDeep, got %v", e)
+	}
+}
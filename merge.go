@@ -0,0 +1,119 @@
+// Copyright (c) 2015 AKUALAB INC., All rights reserved.
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ju
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// MergePatch applies a JSON merge patch (RFC 7386) to base and returns the result.
+// Members with a null value in patch are removed from base, objects are merged
+// recursively and any other value replaces the value in base. The order of the
+// members in base is preserved and new members are appended. An empty base is
+// treated as null.
+func MergePatch(base, patch json.RawMessage) (json.RawMessage, error) {
+	var b interface{}
+	if len(bytes.TrimSpace(base)) > 0 {
+		var err error
+		b, err = decodeOrdered(base)
+		if err != nil {
+			return nil, fmt.Errorf("base: %w", err)
+		}
+	}
+	p, err := decodeOrdered(patch)
+	if err != nil {
+		return nil, fmt.Errorf("patch: %w", err)
+	}
+	return DefaultCodec.Marshal(mergePatch(b, p))
+}
+
+func mergePatch(target, patch interface{}) interface{} {
+	pm, ok := patch.(*OrderedMap)
+	if !ok {
+		return patch
+	}
+	tm, ok := target.(*OrderedMap)
+	if !ok {
+		tm = NewOrderedMap()
+	}
+	for _, k := range pm.Keys() {
+		v, _ := pm.Get(k)
+		if v == nil {
+			tm.Delete(k)
+			continue
+		}
+		cur, _ := tm.Get(k)
+		tm.Set(k, mergePatch(cur, v))
+	}
+	return tm
+}
+
+// decodeOrdered decodes a single json value keeping the order of object members.
+func decodeOrdered(data []byte) (interface{}, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	v, err := decodeOrderedValue(dec)
+	if err != nil {
+		return nil, err
+	}
+	_, err = dec.Token()
+	if err != io.EOF {
+		return nil, fmt.Errorf("unexpected data after json value")
+	}
+	return v, nil
+}
+
+// ApplyMergePatch reads base objects from basePath and patch objects from patchPath,
+// applies the patches to the base objects with the same key using MergePatch, and writes
+// the results to dstPath. Keys are computed with keyFn. Patches with the same key are
+// applied in the order they are read. Base objects without patches are written unchanged,
+// objects for which the result is null are dropped, and patches without a base object
+// are ignored. The patches are kept in memory, the base objects are streamed.
+// See FileStreamer for basePath and patchPath, and NewWriter for dstPath.
+func ApplyMergePatch(basePath, patchPath, dstPath string, keyFn func(json.RawMessage) (string, error)) error {
+
+	patches := map[string][]json.RawMessage{}
+	err := forEachRaw(patchPath, nil, func(raw json.RawMessage) error {
+		key, err := keyFn(raw)
+		if err != nil {
+			return err
+		}
+		patches[key] = append(patches[key], raw)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	w, err := NewWriter(dstPath)
+	if err != nil {
+		return err
+	}
+	err = forEachRaw(basePath, nil, func(raw json.RawMessage) error {
+		key, err := keyFn(raw)
+		if err != nil {
+			return err
+		}
+		for _, p := range patches[key] {
+			raw, err = MergePatch(raw, p)
+			if err != nil {
+				return fmt.Errorf("key %s: %w", key, err)
+			}
+		}
+		if bytes.Equal(raw, []byte("null")) {
+			return nil
+		}
+		return w.Write(raw)
+	})
+	if err != nil {
+		w.Close()
+		return err
+	}
+	return w.Close()
+}
@@ -0,0 +1,94 @@
+// Copyright (c) 2015 AKUALAB INC., All rights reserved.
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ju
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestMergePatch(t *testing.T) {
+
+	// Examples from RFC 7386, appendix A.
+	cases := [][3]string{
+		{`{"a":"b"}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"b"}`, `{"b":"c"}`, `{"a":"b","b":"c"}`},
+		{`{"a":"b"}`, `{"a":null}`, `{}`},
+		{`{"a":"b","b":"c"}`, `{"a":null}`, `{"b":"c"}`},
+		{`{"a":["b"]}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"c"}`, `{"a":["b"]}`, `{"a":["b"]}`},
+		{`{"a":{"b":"c"}}`, `{"a":{"b":"d","c":null}}`, `{"a":{"b":"d"}}`},
+		{`{"a":[{"b":"c"}]}`, `{"a":[1]}`, `{"a":[1]}`},
+		{`["a","b"]`, `["c","d"]`, `["c","d"]`},
+		{`{"a":"b"}`, `["c"]`, `["c"]`},
+		{`{"a":"foo"}`, `null`, `null`},
+		{`{"a":"foo"}`, `"bar"`, `"bar"`},
+		{`{"e":null}`, `{"a":1}`, `{"e":null,"a":1}`},
+		{`[1,2]`, `{"a":"b","c":null}`, `{"a":"b"}`},
+		{``, `{"a":{"bb":{"ccc":null}}}`, `{"a":{"bb":{}}}`},
+		{`{"z":1.50,"y":2}`, `{"y":3e2}`, `{"z":1.50,"y":3e2}`},
+	}
+	for _, c := range cases {
+		res, err := MergePatch(json.RawMessage(c[0]), json.RawMessage(c[1]))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(res) != c[2] {
+			t.Fatalf("merge %s with %s: expected %s, got %s", c[0], c[1], c[2], res)
+		}
+	}
+	_, err := MergePatch(json.RawMessage(`{}`), json.RawMessage(`{"a":`))
+	if err == nil {
+		t.Fatal("expected error for invalid patch")
+	}
+}
+
+func TestApplyMergePatch(t *testing.T) {
+
+	dir := filepath.Join(os.TempDir(), "mergepatch")
+	os.RemoveAll(dir)
+	base := filepath.Join(dir, "base.json")
+	patch := filepath.Join(dir, "patch.json")
+	dst := filepath.Join(dir, "out", "merged.json")
+	e := WriteAll(base, []json.RawMessage{
+		json.RawMessage(`{"id":"1","v":1}`),
+		json.RawMessage(`{"id":"2","v":2}`),
+		json.RawMessage(`{"id":"3","v":3}`),
+	})
+	if e != nil {
+		t.Fatal(e)
+	}
+	e = WriteAll(patch, []json.RawMessage{
+		json.RawMessage(`{"id":"1","v":10}`),
+		json.RawMessage(`{"id":"1","w":true}`),
+		json.RawMessage(`{"id":"4","v":4}`),
+	})
+	if e != nil {
+		t.Fatal(e)
+	}
+	keyFn := func(raw json.RawMessage) (string, error) {
+		var o struct{ ID string }
+		err := json.Unmarshal(raw, &o)
+		return o.ID, err
+	}
+	e = ApplyMergePatch(base, patch, dst, keyFn)
+	if e != nil {
+		t.Fatal(e)
+	}
+	data, e := os.ReadFile(dst)
+	if e != nil {
+		t.Fatal(e)
+	}
+	expected := `{"id":"1","v":10,"w":true}
+{"id":"2","v":2}
+{"id":"3","v":3}
+`
+	if string(data) != expected {
+		t.Fatalf("expected %q, got %q", expected, data)
+	}
+}
@@ -0,0 +1,419 @@
+// Copyright (c) 2015 AKUALAB INC., All rights reserved.
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ju
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrInvalid is wrapped by the errors returned when a json object fails validation.
+var ErrInvalid = errors.New("invalid json object")
+
+// ErrSymlink is returned when a path is a symbolic link and WithRejectSymlinks is set.
+var ErrSymlink = errors.New("path is a symbolic link")
+
+// Option configures the behavior of streamers and writers.
+// Options that do not apply to a function are ignored.
+type Option func(*options)
+
+type options struct {
+	logger     *slog.Logger
+	progress   func(done, total int, path string)
+	heartbeat  func(count int64)
+	heartbeatN int
+	limit      int
+	skip       int
+
+	pollInterval time.Duration
+	stats        bool
+	key          []byte
+	base64       *base64.Encoding
+	fileMode     os.FileMode
+	dirMode      os.FileMode
+	autoArray    bool
+	join         JoinType
+	retries      int
+	backoff      time.Duration
+	recover      bool
+	noSymlinks   bool
+	skipMissing  bool
+	flushEvery   int
+	fullReads    bool
+	recordSep    byte
+	hasRecordSep bool
+	jsonSeq      bool
+	autoID       string
+	autoIDStart  int64
+	comments     bool
+	relaxed      bool
+	maxRecord    int64
+	maxDepth     int
+	blankLines   bool
+	maxFiles     int
+	shuffle      bool
+	seed         int64
+	indexPath    string
+	dict         []byte
+	rate         *rateLimiter
+	ctx          context.Context
+	irregular    bool
+	sortKeys     bool
+
+	// Filters applied to each raw json object before unmarshaling.
+	// A filter may return a modified object.
+	filters     []func(json.RawMessage) (json.RawMessage, error)
+	skipInvalid bool
+
+	// Hooks called on each decoded value.
+	valueHooks []func(interface{}) error
+
+	// Deferred error from an option.
+	err error
+}
+
+func newOptions(opts ...Option) *options {
+	o := &options{
+		logger:       slog.New(slog.DiscardHandler),
+		pollInterval: 250 * time.Millisecond,
+		fileMode:     0666,
+		dirMode:      0755,
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+func (o *options) filter(raw json.RawMessage) (json.RawMessage, error) {
+	var err error
+	for _, f := range o.filters {
+		raw, err = f(raw)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return raw, nil
+}
+
+func (o *options) hook(v interface{}) error {
+	for _, h := range o.valueHooks {
+		err := h(v)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// wrap applies the byte level transformations to a reader of json objects.
+// A leading UTF-8 byte order mark, which is not valid json, is always removed.
+func (o *options) wrap(r io.ReadCloser) io.ReadCloser {
+	r = &bomReader{rc: r}
+	if o.base64 != nil {
+		r = newBase64LineReader(r, o.base64)
+	}
+	if o.comments {
+		r = newCommentReader(r)
+	}
+	if o.relaxed {
+		r = newCommaReader(r)
+	}
+	if o.autoArray {
+		r = newArrayReader(r)
+	}
+	if o.maxDepth > 0 {
+		r = &depthReader{rc: r, max: o.maxDepth}
+	}
+	return r
+}
+
+// newDecoder returns a decoder for a stream of json objects.
+func (o *options) newDecoder(r io.Reader) Decoder {
+	return o.limitRecords(r, func(r io.Reader) Decoder {
+		if o.blankLines {
+			return newBlockDecoder(r)
+		}
+		if o.hasRecordSep {
+			return newSepDecoder(r, o.recordSep)
+		}
+		return DefaultCodec.NewDecoder(r)
+	})
+}
+
+// newEncoder returns an encoder for a stream of json objects.
+func (o *options) newEncoder(w io.Writer) Encoder {
+	if o.jsonSeq {
+		return &sepEncoder{w: w, prefix: []byte{recordSeparator}, sep: '\n'}
+	}
+	if o.hasRecordSep {
+		return &sepEncoder{w: w, sep: o.recordSep}
+	}
+	return DefaultCodec.NewEncoder(w)
+}
+
+// context returns the context set with WithContext, or context.Background.
+func (o *options) context() context.Context {
+	if o.ctx == nil {
+		return context.Background()
+	}
+	return o.ctx
+}
+
+// create creates or truncates a file using the configured permissions.
+// Missing parent directories are created.
+func (o *options) create(path string) (*os.File, error) {
+	e := os.MkdirAll(filepath.Dir(path), o.dirMode)
+	if e != nil {
+		return nil, e
+	}
+	return os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_TRUNC, o.fileMode)
+}
+
+// WithFileMode sets the permissions of files created by writers. (Default 0666.)
+// As with os.Create, the umask is applied and existing files keep their permissions.
+func WithFileMode(mode os.FileMode) Option {
+	return func(o *options) {
+		o.fileMode = mode
+	}
+}
+
+// WithDirMode sets the permissions of parent directories created by writers. (Default 0755.)
+func WithDirMode(mode os.FileMode) Option {
+	return func(o *options) {
+		o.dirMode = mode
+	}
+}
+
+// WithSkipMissing makes a JSONStreamer skip the files listed in a ".list" file that do not exist,
+// instead of failing when it reaches them. Skipped files are logged as warnings and returned
+// by JSONStreamer.Missing.
+func WithSkipMissing() Option {
+	return func(o *options) {
+		o.skipMissing = true
+	}
+}
+
+// WithFlushEvery makes a Writer call Flush after every n objects. For gzipped files, each flush
+// point lets a reader recover all the objects written before it if the end of the file is lost
+// in a crash. Flushing often slightly reduces the compression ratio. It does not sync the file
+// to stable storage; see Writer.Sync.
+func WithFlushEvery(n int) Option {
+	return func(o *options) {
+		o.flushEvery = n
+	}
+}
+
+// WithFullReads makes the reader returned by NewFileStreamer fill the buffer passed to Read,
+// reading across file boundaries as needed. Read returns less data than requested only at
+// the end of the stream or on error.
+func WithFullReads() Option {
+	return func(o *options) {
+		o.fullReads = true
+	}
+}
+
+// WithMaxFiles makes streamers read only the first n files, in the order in which they are
+// listed (see FileStreamer). For zip and tar archives, the first n matching entries are read.
+// Unlike WithLimit, it bounds the number of files opened. Zero means no limit.
+func WithMaxFiles(n int) Option {
+	return func(o *options) {
+		o.maxFiles = n
+	}
+}
+
+// WithShuffle makes streamers read the files in a random order instead of the order in which
+// they are listed. The order is the same for the same seed and list of files. With WithMaxFiles,
+// the files are shuffled before the first n are selected, which reads a random sample of files.
+// The entries of zip archives are also shuffled; tar archives are always read in order.
+func WithShuffle(seed int64) Option {
+	return func(o *options) {
+		o.shuffle = true
+		o.seed = seed
+	}
+}
+
+// WithNonRegularFiles makes streamers read the named pipes, sockets, and device files found in
+// a directory. By default they are skipped since opening them may block forever.
+func WithNonRegularFiles() Option {
+	return func(o *options) {
+		o.irregular = true
+	}
+}
+
+// regular reports whether a file found in a directory should be read. Directories are never read;
+// symbolic links are resolved.
+func (o *options) regular(path string, fi os.FileInfo) bool {
+	if fi.Mode()&os.ModeSymlink != 0 {
+		var err error
+		fi, err = os.Stat(path)
+		if err != nil {
+			return false
+		}
+	}
+	if fi.IsDir() {
+		return false
+	}
+	return o.irregular || fi.Mode().IsRegular()
+}
+
+// WithRejectSymlinks makes streamers fail with ErrSymlink when the path passed to them
+// is a symbolic link. By default, the link is resolved.
+func WithRejectSymlinks() Option {
+	return func(o *options) {
+		o.noSymlinks = true
+	}
+}
+
+// checkSymlink returns ErrSymlink if path is a symbolic link and links are rejected.
+func (o *options) checkSymlink(path string) error {
+	if !o.noSymlinks {
+		return nil
+	}
+	fi, err := os.Lstat(path)
+	if err != nil {
+		return err
+	}
+	if fi.Mode()&os.ModeSymlink != 0 {
+		return fmt.Errorf("%s: %w", path, ErrSymlink)
+	}
+	return nil
+}
+
+// WithLogger sets the logger used to report progress and errors.
+// By default, nothing is logged.
+func WithLogger(l *slog.Logger) Option {
+	return func(o *options) {
+		o.logger = l
+	}
+}
+
+// WithProgress sets a function that is called each time the stream advances to a new file.
+// The arguments are the number of files read so far, the total number of files (-1 if unknown,
+// as with tar archives), and the path of the file about to be read. When all the files are read,
+// fn is called one last time with an empty path.
+func WithProgress(fn func(done, total int, path string)) Option {
+	return func(o *options) {
+		o.progress = fn
+	}
+}
+
+// WithProgressEvery sets a function that is called by a JSONStreamer after every k objects
+// returned by Next, with the number of objects returned so far. Unlike WithProgress, it
+// reports progress within large files.
+func WithProgressEvery(k int, fn func(count int64)) Option {
+	return func(o *options) {
+		o.heartbeatN = k
+		o.heartbeat = fn
+	}
+}
+
+// WithLimit stops a JSONStreamer after n objects. After the nth object is returned,
+// the underlying files are closed and Next returns Done. Zero means no limit.
+func WithLimit(n int) Option {
+	return func(o *options) {
+		o.limit = n
+	}
+}
+
+// WithSkip discards the first n objects of a JSONStreamer. Skipped objects are
+// not unmarshaled. Combine with WithLimit to read a page of objects.
+func WithSkip(n int) Option {
+	return func(o *options) {
+		o.skip = n
+	}
+}
+
+// WithSkipInvalid makes a JSONStreamer skip the objects that fail validation
+// (see WithSchema) instead of returning an error. Skipped objects are logged as warnings.
+func WithSkipInvalid() Option {
+	return func(o *options) {
+		o.skipInvalid = true
+	}
+}
+
+// WithSchema validates each object read by a JSONStreamer against a JSON Schema.
+// Errors wrap ErrInvalid and describe the location of the violation. See Schema
+// for the supported keywords.
+func WithSchema(schemaJSON []byte) Option {
+	return func(o *options) {
+		s, err := CompileSchema(schemaJSON)
+		if err != nil {
+			o.err = err
+			return
+		}
+		o.filters = append(o.filters, func(raw json.RawMessage) (json.RawMessage, error) {
+			return raw, s.Validate(raw)
+		})
+	}
+}
+
+// WithRequiredFields validates that each object read by a JSONStreamer has the given top-level
+// fields with non-null values. It is much cheaper than WithSchema for this common check. Errors wrap
+// ErrInvalid and list the missing fields, so WithSkipInvalid skips these objects.
+func WithRequiredFields(fields []string) Option {
+	return func(o *options) {
+		o.filters = append(o.filters, func(raw json.RawMessage) (json.RawMessage, error) {
+			var m map[string]json.RawMessage
+			err := DefaultCodec.Unmarshal(raw, &m)
+			if err != nil || m == nil {
+				return raw, fmt.Errorf("%w: not an object", ErrInvalid)
+			}
+			var missing []string
+			for _, f := range fields {
+				v, ok := m[f]
+				if !ok || string(v) == "null" {
+					missing = append(missing, strconv.Quote(f))
+				}
+			}
+			if len(missing) > 0 {
+				return raw, fmt.Errorf("%w: missing required fields %s", ErrInvalid, strings.Join(missing, ", "))
+			}
+			return raw, nil
+		})
+	}
+}
+
+// WithRawHook calls fn on the raw bytes of each object read by a JSONStreamer, before unmarshaling.
+// The object is syntactically valid json; fn may return a modified object which is unmarshaled instead.
+// Hooks and schemas run in the order the options are given.
+// If fn returns an error wrapping ErrInvalid and WithSkipInvalid is set, the object is skipped.
+func WithRawHook(fn func([]byte) ([]byte, error)) Option {
+	return func(o *options) {
+		o.filters = append(o.filters, func(raw json.RawMessage) (json.RawMessage, error) {
+			return fn(raw)
+		})
+	}
+}
+
+// WithValueHook calls fn with each value decoded by a JSONStreamer, after unmarshaling.
+// The argument is the destination passed to Next. If fn returns an error wrapping ErrInvalid
+// and WithSkipInvalid is set, the object is skipped.
+func WithValueHook(fn func(interface{}) error) Option {
+	return func(o *options) {
+		o.valueHooks = append(o.valueHooks, fn)
+	}
+}
+
+// WithPollInterval sets how often FollowJSON checks for new data and WatchDir checks for new files.
+// Defaults to 250ms.
+func WithPollInterval(d time.Duration) Option {
+	return func(o *options) {
+		if d > 0 {
+			o.pollInterval = d
+		}
+	}
+}
@@ -0,0 +1,171 @@
+// Copyright (c) 2015 AKUALAB INC., All rights reserved.
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ju
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+)
+
+// OrderedMap is a json object that remembers the order of its keys.
+// When unmarshaling, nested objects are decoded as *OrderedMap, arrays as []interface{},
+// and numbers as json.Number so that objects can be written back without changes.
+// The zero value is an empty map ready to use.
+type OrderedMap struct {
+	keys   []string
+	values map[string]interface{}
+}
+
+// NewOrderedMap creates an empty ordered map.
+func NewOrderedMap() *OrderedMap {
+	return &OrderedMap{values: map[string]interface{}{}}
+}
+
+// Keys returns the keys in order. The returned slice must not be modified.
+func (om *OrderedMap) Keys() []string {
+	return om.keys
+}
+
+// Len returns the number of keys.
+func (om *OrderedMap) Len() int {
+	return len(om.keys)
+}
+
+// Get returns the value for key k.
+func (om *OrderedMap) Get(k string) (interface{}, bool) {
+	v, ok := om.values[k]
+	return v, ok
+}
+
+// Set sets the value for key k. New keys are appended at the end,
+// existing keys keep their position.
+func (om *OrderedMap) Set(k string, v interface{}) {
+	if om.values == nil {
+		om.values = map[string]interface{}{}
+	}
+	if _, ok := om.values[k]; !ok {
+		om.keys = append(om.keys, k)
+	}
+	om.values[k] = v
+}
+
+// Delete removes key k.
+func (om *OrderedMap) Delete(k string) {
+	if _, ok := om.values[k]; !ok {
+		return
+	}
+	delete(om.values, k)
+	for i, v := range om.keys {
+		if v == k {
+			om.keys = append(om.keys[:i], om.keys[i+1:]...)
+			break
+		}
+	}
+}
+
+// MarshalJSON implements the json.Marshaler interface. Keys are written in order.
+func (om *OrderedMap) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, k := range om.keys {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		kb, err := DefaultCodec.Marshal(k)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(kb)
+		buf.WriteByte(':')
+		vb, err := DefaultCodec.Marshal(om.values[k])
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(vb)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface.
+func (om *OrderedMap) UnmarshalJSON(data []byte) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	tok, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	if tok != json.Delim('{') {
+		return fmt.Errorf("cannot unmarshal %v into OrderedMap", tok)
+	}
+	om.keys = nil
+	om.values = map[string]interface{}{}
+	return om.decode(dec)
+}
+
+// decode reads the object members after the opening brace.
+func (om *OrderedMap) decode(dec *json.Decoder) error {
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		k, ok := tok.(string)
+		if !ok {
+			return fmt.Errorf("expected object key, got %v", tok)
+		}
+		v, err := decodeOrderedValue(dec)
+		if err != nil {
+			return err
+		}
+		om.Set(k, v)
+	}
+	_, err := dec.Token() // '}'
+	return err
+}
+
+func decodeOrderedValue(dec *json.Decoder) (interface{}, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+	switch tok {
+	case json.Delim('{'):
+		om := NewOrderedMap()
+		err := om.decode(dec)
+		if err != nil {
+			return nil, err
+		}
+		return om, nil
+	case json.Delim('['):
+		a := []interface{}{}
+		for dec.More() {
+			v, err := decodeOrderedValue(dec)
+			if err != nil {
+				return nil, err
+			}
+			a = append(a, v)
+		}
+		_, err := dec.Token() // ']'
+		if err != nil {
+			return nil, err
+		}
+		return a, nil
+	}
+	return tok, nil
+}
+
+// NextOrdered returns the next JSON object as an ordered map.
+// When there are no more results, Done is returned as the error.
+func (js *JSONStreamer) NextOrdered() (*OrderedMap, error) {
+	om := NewOrderedMap()
+	err := js.Next(om)
+	if err != nil {
+		return nil, err
+	}
+	return om, nil
+}
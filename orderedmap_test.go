@@ -0,0 +1,73 @@
+// Copyright (c) 2015 AKUALAB INC., All rights reserved.
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ju
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestOrderedMap(t *testing.T) {
+
+	lines := []string{
+		`{"z":1,"a":{"y":[1,{"q":true,"b":null}],"x":"s"},"m":1.50}`,
+		`{"b":2,"a":1,"b":3}`,
+	}
+	expected := []string{
+		`{"z":1,"a":{"y":[1,{"q":true,"b":null}],"x":"s"},"m":1.50}`,
+		`{"b":3,"a":1}`,
+	}
+	fn := filepath.Join(os.TempDir(), "ordered", "ordered.json")
+	e := os.MkdirAll(filepath.Dir(fn), 0777)
+	if e != nil {
+		t.Fatal(e)
+	}
+	var buf bytes.Buffer
+	for _, v := range lines {
+		buf.WriteString(v + "\n")
+	}
+	e = os.WriteFile(fn, buf.Bytes(), 0644)
+	if e != nil {
+		t.Fatal(e)
+	}
+
+	js, err := NewJSONStreamer(fn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer js.Close()
+	for i := 0; ; i++ {
+		om, e := js.NextOrdered()
+		if e == Done {
+			break
+		}
+		if e != nil {
+			t.Fatal(e)
+		}
+		b, err := om.MarshalJSON()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(b) != expected[i] {
+			t.Fatalf("expected %s, got %s", expected[i], b)
+		}
+	}
+
+	om := NewOrderedMap()
+	om.Set("c", 1)
+	om.Set("a", 2)
+	om.Set("b", 3)
+	om.Delete("a")
+	b, err := om.MarshalJSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != `{"c":1,"b":3}` {
+		t.Fatalf("unexpected %s", b)
+	}
+}
@@ -0,0 +1,206 @@
+// Copyright (c) 2015 AKUALAB INC., All rights reserved.
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ju
+
+import (
+	"context"
+	"fmt"
+	"io"
+)
+
+// CollectParallel reads all the json objects in path in parallel, like ReadJSONParallel, and returns them in a slice.
+// The order of the objects is not deterministic.
+// Objects are decoded directly into values of type T, without reflection.
+func CollectParallel[T any](path string, numWorkers int, opts ...Option) ([]T, error) {
+
+	out, errCh := ParallelStream[T](path, numWorkers, opts...)
+	var objs []T
+	for x := range out {
+		objs = append(objs, x)
+	}
+	e := <-errCh
+	if e != nil {
+		return nil, e
+	}
+	return objs, nil
+}
+
+// ParallelStream reads the json objects in path using numWorkers goroutines, like ReadJSONParallel,
+// and sends them to the returned values channel. Objects are decoded directly into values of type T.
+// When done, the values channel is closed, then the error channel receives the first error,
+// if any, and is closed. The caller must drain the values channel.
+func ParallelStream[T any](path string, numWorkers int, opts ...Option) (<-chan T, <-chan error) {
+
+	o := newOptions(opts...)
+	errCh := make(chan error, 1)
+	if numWorkers < 1 {
+		out := make(chan T)
+		close(out)
+		errCh <- fmt.Errorf("invalid number of workers: %d", numWorkers)
+		close(errCh)
+		return out, errCh
+	}
+	out := make(chan T, numWorkers)
+	go func() {
+		err := parallelFiles(o.context(), path, numWorkers, o, func(ctx context.Context, path string) error {
+			return typedWorker(ctx, path, out, o)
+		})
+		close(out)
+		if err != nil {
+			errCh <- err
+		}
+		close(errCh)
+	}()
+	return out, errCh
+}
+
+func typedWorker[T any](ctx context.Context, path string, out chan<- T, o *options) error {
+
+	reader, err := streamFile(path, o)
+	if err != nil {
+		return err
+	}
+	reader = o.wrap(reader)
+	defer reader.Close()
+	dec := o.newDecoder(reader)
+	n := 0
+	for {
+		if ctx.Err() != nil {
+			return context.Canceled
+		}
+		var x T
+		e := dec.Decode(&x)
+		if e == io.EOF {
+			o.logger.Debug("read records", "records", n, "path", path)
+			return nil
+		}
+		if e != nil {
+			return e
+		}
+		select {
+		case out <- x:
+		case <-ctx.Done():
+			return context.Canceled
+		}
+		n++
+	}
+}
+
+// ParallelBatches is like ParallelStream but sends the objects in slices of up to batchSize objects,
+// which reduces the number of channel operations. Batches do not span files; the last batch of
+// each file may be smaller than batchSize. Each batch is a new slice owned by the receiver.
+func ParallelBatches[T any](path string, numWorkers, batchSize int, opts ...Option) (<-chan []T, <-chan error) {
+
+	o := newOptions(opts...)
+	errCh := make(chan error, 1)
+	if numWorkers < 1 || batchSize < 1 {
+		out := make(chan []T)
+		close(out)
+		if numWorkers < 1 {
+			errCh <- fmt.Errorf("invalid number of workers: %d", numWorkers)
+		} else {
+			errCh <- fmt.Errorf("invalid batch size: %d", batchSize)
+		}
+		close(errCh)
+		return out, errCh
+	}
+	out := make(chan []T, numWorkers)
+	go func() {
+		err := parallelFiles(o.context(), path, numWorkers, o, func(ctx context.Context, path string) error {
+			return batchWorker(ctx, path, batchSize, out, o)
+		})
+		close(out)
+		if err != nil {
+			errCh <- err
+		}
+		close(errCh)
+	}()
+	return out, errCh
+}
+
+func batchWorker[T any](ctx context.Context, path string, batchSize int, out chan<- []T, o *options) error {
+
+	reader, err := streamFile(path, o)
+	if err != nil {
+		return err
+	}
+	reader = o.wrap(reader)
+	defer reader.Close()
+	dec := o.newDecoder(reader)
+	batch := make([]T, 0, batchSize)
+	send := func() error {
+		select {
+		case out <- batch:
+		case <-ctx.Done():
+			return context.Canceled
+		}
+		batch = make([]T, 0, batchSize)
+		return nil
+	}
+	n := 0
+	for {
+		if ctx.Err() != nil {
+			return context.Canceled
+		}
+		var x T
+		e := dec.Decode(&x)
+		if e == io.EOF {
+			o.logger.Debug("read records", "records", n, "path", path)
+			if len(batch) > 0 {
+				return send()
+			}
+			return nil
+		}
+		if e != nil {
+			return e
+		}
+		batch = append(batch, x)
+		n++
+		if len(batch) == batchSize {
+			e = send()
+			if e != nil {
+				return e
+			}
+		}
+	}
+}
+
+// StreamChan decodes the json objects in r sequentially in a background goroutine and sends them
+// to the returned values channel, with a buffer of bufSize values. It is the single reader analog
+// of ParallelStream and keeps the order of the objects. When r is consumed, the values channel is
+// closed, then the error channel receives the decoding error, if any, and is closed. The caller
+// must drain the values channel. A UTF-8 byte order mark at the beginning of r is removed.
+func StreamChan[T any](r io.Reader, bufSize int) (<-chan T, <-chan error) {
+
+	if bufSize < 0 {
+		bufSize = 0
+	}
+	out := make(chan T, bufSize)
+	errCh := make(chan error, 1)
+	go func() {
+		o := newOptions()
+		dec := o.newDecoder(o.wrap(io.NopCloser(r)))
+		var err error
+		for {
+			var x T
+			e := dec.Decode(&x)
+			if e == io.EOF {
+				break
+			}
+			if e != nil {
+				err = e
+				break
+			}
+			out <- x
+		}
+		close(out)
+		if err != nil {
+			errCh <- err
+		}
+		close(errCh)
+	}()
+	return out, errCh
+}
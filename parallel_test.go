@@ -0,0 +1,175 @@
+// Copyright (c) 2015 AKUALAB INC., All rights reserved.
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ju
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeParallelFiles(t *testing.T, name string, numFiles, numObjs int) string {
+	dir := filepath.Join(os.TempDir(), name)
+	os.RemoveAll(dir)
+	for k := 0; k < numFiles; k++ {
+		objs := []tt{}
+		for i := 0; i < numObjs; i++ {
+			objs = append(objs, tt{Name: name, N: k*numObjs + i})
+		}
+		e := WriteAll(filepath.Join(dir, fmt.Sprintf("testfile-%d.json", k)), objs)
+		if e != nil {
+			t.Fatal(e)
+		}
+	}
+	return dir
+}
+
+func TestCollectParallel(t *testing.T) {
+
+	dir := writeParallelFiles(t, "collect", 5, 20)
+	objs, err := CollectParallel[tt](dir, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	seen := map[int]bool{}
+	for _, o := range objs {
+		seen[o.N] = true
+	}
+	if len(objs) != 100 || len(seen) != 100 {
+		t.Fatalf("expected 100 distinct objects, got %d (%d distinct)", len(objs), len(seen))
+	}
+
+	_, err = CollectParallel[tt](filepath.Join(dir, "missing"), 3)
+	if err == nil {
+		t.Fatal("expected error for missing path")
+	}
+}
+
+func TestParallelStream(t *testing.T) {
+
+	dir := writeParallelFiles(t, "parallelstream", 5, 20)
+	values, errs := ParallelStream[tt](dir, 3)
+	seen := map[int]bool{}
+	for o := range values {
+		seen[o.N] = true
+	}
+	for e := range errs {
+		t.Fatal(e)
+	}
+	if len(seen) != 100 {
+		t.Fatalf("expected 100 distinct objects, got %d", len(seen))
+	}
+
+	e := os.WriteFile(filepath.Join(dir, "testfile-bad.json"), []byte(`{"N":"x"}`), 0644)
+	if e != nil {
+		t.Fatal(e)
+	}
+	values, errs = ParallelStream[tt](dir, 3)
+	for range values {
+	}
+	if e := <-errs; e == nil {
+		t.Fatal("expected decode error")
+	}
+}
+
+func TestParallelBatches(t *testing.T) {
+
+	dir := writeParallelFiles(t, "parallelbatches", 5, 23)
+	batches, errs := ParallelBatches[tt](dir, 3, 10)
+	seen := map[int]bool{}
+	sizes := map[int]int{}
+	for b := range batches {
+		sizes[len(b)]++
+		for _, o := range b {
+			seen[o.N] = true
+		}
+	}
+	for e := range errs {
+		t.Fatal(e)
+	}
+	if len(seen) != 115 {
+		t.Fatalf("expected 115 distinct objects, got %d", len(seen))
+	}
+	// Each file has two full batches and a partial batch of 3.
+	if sizes[10] != 10 || sizes[3] != 5 || len(sizes) != 2 {
+		t.Fatalf("unexpected batch sizes: %v", sizes)
+	}
+
+	batches, errs = ParallelBatches[tt](dir, 3, 0)
+	for range batches {
+	}
+	if e := <-errs; e == nil {
+		t.Fatal("expected error for invalid batch size")
+	}
+}
+
+func TestStreamChan(t *testing.T) {
+
+	values, errs := StreamChan[tt](strings.NewReader("\xef\xbb\xbf{\"N\":0}\n{\"N\":1}\n{\"N\":2}\n"), 1)
+	n := 0
+	for o := range values {
+		if o.N != n {
+			t.Fatalf("expected object %d, got %d", n, o.N)
+		}
+		n++
+	}
+	for e := range errs {
+		t.Fatal(e)
+	}
+	if n != 3 {
+		t.Fatalf("expected 3 objects, got %d", n)
+	}
+
+	values, errs = StreamChan[tt](strings.NewReader(`{"N":0} {"N":"x"} {"N":2}`), 0)
+	n = 0
+	for range values {
+		n++
+	}
+	if e := <-errs; e == nil || n != 1 {
+		t.Fatalf("expected 1 object and a decode error, got %d objects and %v", n, e)
+	}
+}
+
+func TestParallelInvalidWorkers(t *testing.T) {
+
+	dir := writeParallelFiles(t, "parallelworkers", 12, 2)
+	for _, n := range []int{0, -1} {
+		_, err := CollectParallel[tt](dir, n)
+		if err == nil {
+			t.Fatalf("%d workers: expected an error", n)
+		}
+		batches, errs := ParallelBatches[tt](dir, n, 10)
+		for range batches {
+		}
+		if e := <-errs; e == nil {
+			t.Fatalf("%d workers: expected an error from ParallelBatches", n)
+		}
+		e := ReadJSONParallel(dir, &tt{}, make(chan interface{}), n)
+		if e == nil {
+			t.Fatalf("%d workers: expected an error from ReadJSONParallel", n)
+		}
+	}
+}
+
+func TestParallelInvalidOption(t *testing.T) {
+
+	dir := writeParallelFiles(t, "paralleloption", 2, 2)
+	bad := WithSchema([]byte(`{"type":`))
+	e := ReadJSONParallel(dir, &tt{}, make(chan interface{}, 10), 2, bad)
+	if e == nil {
+		t.Fatal("expected a schema error from ReadJSONParallel")
+	}
+	values, errs := ParallelStream[tt](dir, 2, bad)
+	n := 0
+	for range values {
+		n++
+	}
+	if e := <-errs; e == nil || n != 0 {
+		t.Fatalf("expected a schema error and no objects from ParallelStream, got %v and %d objects", e, n)
+	}
+}
@@ -0,0 +1,65 @@
+// Copyright (c) 2015 AKUALAB INC., All rights reserved.
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ju
+
+import (
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"path/filepath"
+)
+
+// Partition reads json objects from srcPath and writes each object to one of n files
+// in dstDir named part-<bucket>.json, where bucket is the FNV-1a hash of the key returned
+// by keyFn modulo n. Objects with the same key always go to the same bucket, so the
+// partition is reproducible. All n files are created, even if some are empty.
+// Returns the number of objects written to each bucket.
+// See FileStreamer for srcPath and ext.
+func Partition(srcPath, dstDir string, n int, keyFn func(json.RawMessage) (string, error), ext ...string) ([]int64, error) {
+
+	if n < 1 {
+		return nil, fmt.Errorf("invalid number of buckets: %d", n)
+	}
+	writers := make([]*Writer, n)
+	closeAll := func() error {
+		var firstErr error
+		for _, w := range writers {
+			if w == nil {
+				continue
+			}
+			err := w.Close()
+			if err != nil && firstErr == nil {
+				firstErr = err
+			}
+		}
+		return firstErr
+	}
+	for i := range writers {
+		w, err := NewWriter(filepath.Join(dstDir, fmt.Sprintf("part-%d.json", i)))
+		if err != nil {
+			closeAll()
+			return nil, err
+		}
+		writers[i] = w
+	}
+	counts := make([]int64, n)
+	err := forEachRaw(srcPath, ext, func(raw json.RawMessage) error {
+		key, err := keyFn(raw)
+		if err != nil {
+			return err
+		}
+		h := fnv.New32a()
+		h.Write([]byte(key))
+		b := h.Sum32() % uint32(n)
+		counts[b]++
+		return writers[b].Write(raw)
+	})
+	if err != nil {
+		closeAll()
+		return counts, err
+	}
+	return counts, closeAll()
+}
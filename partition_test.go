@@ -0,0 +1,74 @@
+// Copyright (c) 2015 AKUALAB INC., All rights reserved.
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ju
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestPartition(t *testing.T) {
+
+	src := filepath.Join(os.TempDir(), "partition", "src.json.gz")
+	data := make([]tt, 500)
+	for i := range data {
+		data[i] = tt{Name: fmt.Sprint("user-", i%37), N: i}
+	}
+	e := WriteAll(src, data)
+	if e != nil {
+		t.Fatal(e)
+	}
+	key := func(raw json.RawMessage) (string, error) {
+		var x tt
+		err := json.Unmarshal(raw, &x)
+		return x.Name, err
+	}
+
+	dst := filepath.Join(os.TempDir(), "partition", "out")
+	os.RemoveAll(dst)
+	counts, err := Partition(src, dst, 4, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var total int64
+	bucket := map[string]int{}
+	for b, c := range counts {
+		objs, err := CollectParallel[tt](filepath.Join(dst, fmt.Sprintf("part-%d.json", b)), 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if int64(len(objs)) != c {
+			t.Fatalf("bucket %d: expected %d objects, got %d", b, c, len(objs))
+		}
+		for _, x := range objs {
+			if prev, ok := bucket[x.Name]; ok && prev != b {
+				t.Fatalf("key %s in buckets %d and %d", x.Name, prev, b)
+			}
+			bucket[x.Name] = b
+		}
+		total += c
+	}
+	if total != int64(len(data)) {
+		t.Fatalf("expected %d objects, got %d", len(data), total)
+	}
+
+	// Reproducible.
+	again, err := Partition(src, dst, 4, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fmt.Sprint(again) != fmt.Sprint(counts) {
+		t.Fatalf("expected %v, got %v", counts, again)
+	}
+
+	_, err = Partition(src, dst, 0, key)
+	if err == nil {
+		t.Fatal("expected error for zero buckets")
+	}
+}
@@ -0,0 +1,36 @@
+// Copyright (c) 2015 AKUALAB INC., All rights reserved.
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ju
+
+import (
+	"encoding/json"
+)
+
+// Pipe reads json objects from srcPath, applies transform, and writes the results to dstPath.
+// The object is dropped if transform returns false. Objects are read only as fast as they are
+// written. Returns the number of objects read and written; the number dropped is read - written.
+// See FileStreamer for srcPath and ext, and NewWriter for dstPath.
+func Pipe(srcPath, dstPath string, transform func(json.RawMessage) (json.RawMessage, bool, error), ext ...string) (read, written int64, err error) {
+
+	w, err := NewWriter(dstPath)
+	if err != nil {
+		return 0, 0, err
+	}
+	err = forEachRaw(srcPath, ext, func(raw json.RawMessage) error {
+		read++
+		out, keep, err := transform(raw)
+		if err != nil || !keep {
+			return err
+		}
+		written++
+		return w.Write(out)
+	})
+	if err != nil {
+		w.Close()
+		return read, written, err
+	}
+	return read, written, w.Close()
+}
@@ -0,0 +1,63 @@
+// Copyright (c) 2015 AKUALAB INC., All rights reserved.
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ju
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestPipe(t *testing.T) {
+
+	dir := filepath.Join(os.TempDir(), "pipe")
+	os.RemoveAll(dir)
+	src := filepath.Join(dir, "src.json.gz")
+	dst := filepath.Join(dir, "out", "dst.json.gz")
+	var objs []tt
+	for i := 0; i < 10; i++ {
+		objs = append(objs, tt{Name: "pipe", N: i})
+	}
+	e := WriteAll(src, objs)
+	if e != nil {
+		t.Fatal(e)
+	}
+	read, written, err := Pipe(src, dst, func(raw json.RawMessage) (json.RawMessage, bool, error) {
+		var o tt
+		err := json.Unmarshal(raw, &o)
+		if err != nil || o.N%2 == 1 {
+			return nil, false, err
+		}
+		o.N *= 10
+		out, err := json.Marshal(o)
+		return out, true, err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if read != 10 || written != 5 {
+		t.Fatalf("expected 10 read and 5 written, got %d and %d", read, written)
+	}
+	js, err := NewJSONStreamer(dst)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer js.Close()
+	for i := 0; ; i++ {
+		var o tt
+		e := js.Next(&o)
+		if e == Done {
+			break
+		}
+		if e != nil {
+			t.Fatal(e)
+		}
+		if o.N != i*20 {
+			t.Fatalf("expected %d, got %d", i*20, o.N)
+		}
+	}
+}
@@ -0,0 +1,164 @@
+// Copyright (c) 2015 AKUALAB INC., All rights reserved.
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ju
+
+import (
+	"errors"
+	"hash/fnv"
+	"sync"
+)
+
+// ErrPoolClosed is returned when writing to a WriterPool after Close.
+var ErrPoolClosed = errors.New("writer pool is closed")
+
+// WriterPool writes json objects to multiple files using a fixed number of goroutines.
+// Writers are opened on demand and cached per path. Each path is owned by a single worker,
+// chosen by hashing the path, so the objects of a path are written in the order they are
+// passed to Write by a goroutine. Files are created using NewWriter, if the ext is "gz",
+// the data is gzipped.
+//
+// Each object is written to a single file and is encoded completely before any of it is
+// written, so an object that fails to encode leaves no partial record behind. After the first
+// error, Write returns the error and no more objects are queued; objects that were already
+// queued may still be written to other files. Close always closes every writer.
+type WriterPool struct {
+	jobs    []chan poolJob
+	wg      sync.WaitGroup
+	sendMu  sync.RWMutex // guards sending on jobs against Close
+	mu      sync.Mutex
+	writers map[string]*poolWriter
+	opts    []Option
+	err     error
+	closed  bool
+}
+
+type poolJob struct {
+	path string
+	obj  interface{}
+}
+
+type poolWriter struct {
+	sync.Mutex
+	w   *Writer
+	err error
+}
+
+// NewWriterPool creates a pool that writes using numWorkers goroutines.
+// The options are passed to NewWriter when a writer is opened.
+// It is the caller's responsibility to call Close when done.
+func NewWriterPool(numWorkers int, opts ...Option) *WriterPool {
+	if numWorkers < 1 {
+		numWorkers = 1
+	}
+	p := &WriterPool{
+		jobs:    make([]chan poolJob, numWorkers),
+		writers: make(map[string]*poolWriter),
+		opts:    opts,
+	}
+	p.wg.Add(numWorkers)
+	for w := range p.jobs {
+		jobs := make(chan poolJob, numWorkers)
+		p.jobs[w] = jobs
+		go func() {
+			for j := range jobs {
+				p.write(j)
+			}
+			p.wg.Done()
+		}()
+	}
+	return p
+}
+
+// Write queues object o to be written to path. The object is encoded asynchronously
+// so the caller must not modify it after calling Write.
+// Write returns the first error encountered by the pool so far, if any.
+func (p *WriterPool) Write(path string, o interface{}) error {
+	p.sendMu.RLock()
+	defer p.sendMu.RUnlock()
+	p.mu.Lock()
+	if p.closed {
+		p.mu.Unlock()
+		return ErrPoolClosed
+	}
+	err := p.err
+	p.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	p.jobs[workerFor(path, len(p.jobs))] <- poolJob{path: path, obj: o}
+	return nil
+}
+
+// workerFor returns the worker, out of n, that owns name.
+func workerFor(name string, n int) int {
+	h := fnv.New32a()
+	h.Write([]byte(name))
+	return int(h.Sum32() % uint32(n))
+}
+
+// Close waits for all queued objects to be written, then closes all the writers.
+// All writers are closed even if errors occurred. Returns the first error.
+func (p *WriterPool) Close() error {
+	p.sendMu.Lock()
+	p.mu.Lock()
+	if p.closed {
+		p.mu.Unlock()
+		p.sendMu.Unlock()
+		return nil
+	}
+	p.closed = true
+	p.mu.Unlock()
+	for _, jobs := range p.jobs {
+		close(jobs)
+	}
+	p.sendMu.Unlock()
+
+	p.wg.Wait()
+
+	for _, pw := range p.writers {
+		if pw.w == nil {
+			continue
+		}
+		p.setErr(pw.w.Close())
+	}
+	return p.err
+}
+
+func (p *WriterPool) write(j poolJob) {
+
+	p.mu.Lock()
+	pw, ok := p.writers[j.path]
+	if !ok {
+		pw = &poolWriter{}
+		p.writers[j.path] = pw
+	}
+	p.mu.Unlock()
+
+	pw.Lock()
+	defer pw.Unlock()
+	if pw.err != nil {
+		return
+	}
+	if pw.w == nil {
+		pw.w, pw.err = NewWriter(j.path, p.opts...)
+		if pw.err != nil {
+			p.setErr(pw.err)
+			return
+		}
+	}
+	p.setErr(pw.w.Write(j.obj))
+}
+
+func (p *WriterPool) setErr(err error) {
+	if err == nil {
+		return
+	}
+	p.mu.Lock()
+	if p.err == nil {
+		p.err = err
+	}
+	p.mu.Unlock()
+}
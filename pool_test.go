@@ -0,0 +1,173 @@
+// Copyright (c) 2015 AKUALAB INC., All rights reserved.
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ju
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestWriterPool(t *testing.T) {
+
+	dir := filepath.Join(os.TempDir(), "pool")
+	os.RemoveAll(dir)
+	pool := NewWriterPool(4)
+	for i := 0; i < 300; i++ {
+		fn := filepath.Join(dir, fmt.Sprintf("part-%d.json", i%3))
+		if i%2 == 0 {
+			fn += ".gz"
+		}
+		x := tt{Name: fn, N: i}
+		e := pool.Write(fn, &x)
+		if e != nil {
+			t.Fatal(e)
+		}
+	}
+	e := pool.Close()
+	if e != nil {
+		t.Fatal(e)
+	}
+	e = pool.Write(filepath.Join(dir, "late.json"), &tt{})
+	if e != ErrPoolClosed {
+		t.Fatalf("expected ErrPoolClosed, got %v", e)
+	}
+
+	js, err := NewJSONStreamer(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	seen := map[int]bool{}
+	for {
+		var o tt
+		e := js.Next(&o)
+		if e == Done {
+			break
+		}
+		if e != nil {
+			t.Fatal(e)
+		}
+		if seen[o.N] {
+			t.Fatalf("duplicate object %d", o.N)
+		}
+		seen[o.N] = true
+	}
+	js.Close()
+	if len(seen) != 300 {
+		t.Fatalf("expected 300 objects, got %d", len(seen))
+	}
+}
+
+func TestWriterPoolError(t *testing.T) {
+
+	dir := filepath.Join(os.TempDir(), "poolerror")
+	os.RemoveAll(dir)
+	pool := NewWriterPool(2)
+	fns := []string{filepath.Join(dir, "a.json"), filepath.Join(dir, "b.json.gz"), filepath.Join(dir, "c.json")}
+	for i, fn := range fns {
+		e := pool.Write(fn, &tt{N: i})
+		if e != nil {
+			t.Fatal(e)
+		}
+	}
+	// An object that cannot be encoded.
+	e := pool.Write(fns[1], map[string]interface{}{"f": func() {}})
+	if e != nil {
+		t.Fatal(e)
+	}
+	e = pool.Close()
+	if e == nil {
+		t.Fatal("expected encoding error")
+	}
+
+	// All the files were closed and contain only complete records.
+	for i, fn := range fns {
+		js, err := NewJSONStreamer(fn)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var o tt
+		e := js.Next(&o)
+		if e != nil || o.N != i {
+			t.Fatalf("%s: expected object %d, got %v, %v", fn, i, o, e)
+		}
+		e = js.Next(&o)
+		if e != Done {
+			t.Fatalf("%s: expected Done, got %v", fn, e)
+		}
+		js.Close()
+	}
+}
+
+func TestWriterPoolOrder(t *testing.T) {
+
+	dir := filepath.Join(os.TempDir(), "poolorder")
+	os.RemoveAll(dir)
+	pool := NewWriterPool(16)
+	for i := 0; i < 20000; i++ {
+		fn := filepath.Join(dir, fmt.Sprintf("part-%d.json", i%5))
+		e := pool.Write(fn, &tt{N: i})
+		if e != nil {
+			t.Fatal(e)
+		}
+	}
+	e := pool.Close()
+	if e != nil {
+		t.Fatal(e)
+	}
+
+	for k := 0; k < 5; k++ {
+		js, err := NewJSONStreamer(filepath.Join(dir, fmt.Sprintf("part-%d.json", k)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		var objs []tt
+		for {
+			var o tt
+			e := js.Next(&o)
+			if e == Done {
+				break
+			}
+			if e != nil {
+				t.Fatal(e)
+			}
+			objs = append(objs, o)
+		}
+		js.Close()
+		if len(objs) != 4000 {
+			t.Fatalf("part %d: expected 4000 objects, got %d", k, len(objs))
+		}
+		for i, o := range objs {
+			if o.N != k+5*i {
+				t.Fatalf("part %d: expected object %d at position %d, got %d", k, k+5*i, i, o.N)
+			}
+		}
+	}
+}
+
+func TestWriterPoolOptions(t *testing.T) {
+
+	dir := filepath.Join(os.TempDir(), "pooloptions")
+	os.RemoveAll(dir)
+	pool := NewWriterPool(2, WithFileMode(0600))
+	fn := filepath.Join(dir, "a.json")
+	e := pool.Write(fn, &tt{N: 1})
+	if e != nil {
+		t.Fatal(e)
+	}
+	e = pool.Close()
+	if e != nil {
+		t.Fatal(e)
+	}
+	fi, err := os.Stat(fn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fi.Mode().Perm() != 0600 {
+		t.Fatalf("expected mode 0600, got %v", fi.Mode().Perm())
+	}
+}
@@ -0,0 +1,34 @@
+// Copyright (c) 2015 AKUALAB INC., All rights reserved.
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ju
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+)
+
+// PrettyPrint writes the json objects in srcPath to w indented with two spaces.
+// Objects are streamed one at a time and separated by a newline.
+// See FileStreamer for srcPath and ext.
+func PrettyPrint(srcPath string, w io.Writer, ext ...string) error {
+	return PrettyPrintIndent(srcPath, w, "  ", ext...)
+}
+
+// PrettyPrintIndent is like PrettyPrint but indents with the given string.
+func PrettyPrintIndent(srcPath string, w io.Writer, indent string, ext ...string) error {
+	var buf bytes.Buffer
+	return forEachRaw(srcPath, ext, func(raw json.RawMessage) error {
+		buf.Reset()
+		err := json.Indent(&buf, raw, "", indent)
+		if err != nil {
+			return err
+		}
+		buf.WriteByte('\n')
+		_, err = w.Write(buf.Bytes())
+		return err
+	})
+}
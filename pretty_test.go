@@ -0,0 +1,52 @@
+// Copyright (c) 2015 AKUALAB INC., All rights reserved.
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ju
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestPrettyPrint(t *testing.T) {
+
+	fn := filepath.Join(os.TempDir(), "pretty", "p.json")
+	e := WriteAll(fn, []tt{{Name: "a", N: 1, Words: []string{"x"}}, {Name: "b"}})
+	if e != nil {
+		t.Fatal(e)
+	}
+	var buf bytes.Buffer
+	e = PrettyPrint(fn, &buf)
+	if e != nil {
+		t.Fatal(e)
+	}
+	expected := `{
+  "Name": "a",
+  "N": 1,
+  "Words": [
+    "x"
+  ]
+}
+{
+  "Name": "b",
+  "N": 0,
+  "Words": null
+}
+`
+	if buf.String() != expected {
+		t.Fatalf("expected %q, got %q", expected, buf.String())
+	}
+
+	buf.Reset()
+	e = PrettyPrintIndent(fn, &buf, "\t")
+	if e != nil {
+		t.Fatal(e)
+	}
+	if !bytes.HasPrefix(buf.Bytes(), []byte("{\n\t\"Name\": \"a\",\n")) {
+		t.Fatalf("unexpected output %q", buf.String())
+	}
+}
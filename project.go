@@ -0,0 +1,110 @@
+// Copyright (c) 2015 AKUALAB INC., All rights reserved.
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ju
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Projector keeps only a list of fields of json objects.
+// The zero value keeps top level keys only.
+type Projector struct {
+	// Nested interprets the keys as paths to nested fields, for example "a.b" keeps
+	// field "b" of object "a" and drops the other fields of "a".
+	Nested bool
+	// Separator between path elements when Nested is true. Defaults to ".".
+	Separator string
+}
+
+// Project reads json objects from srcPath and writes to dstPath only the top level
+// fields listed in keep, using the default Projector.
+// See FileStreamer for srcPath and ext, and NewWriter for dstPath.
+func Project(srcPath, dstPath string, keep []string, ext ...string) error {
+	return Projector{}.Project(srcPath, dstPath, keep, ext...)
+}
+
+// projection is a tree of the fields to keep. A nil subtree keeps the whole value.
+type projection map[string]projection
+
+func (p Projector) projection(keep []string) projection {
+	sep := p.Separator
+	if sep == "" {
+		sep = "."
+	}
+	root := projection{}
+	for _, k := range keep {
+		parts := []string{k}
+		if p.Nested {
+			parts = strings.Split(k, sep)
+		}
+		node := root
+		for i, part := range parts {
+			child, ok := node[part]
+			if ok && child == nil {
+				break // the whole value is kept
+			}
+			if i == len(parts)-1 {
+				node[part] = nil
+				break
+			}
+			if !ok {
+				child = projection{}
+				node[part] = child
+			}
+			node = child
+		}
+	}
+	return root
+}
+
+// apply returns a copy of om with only the fields in the projection, in the original order.
+func (pr projection) apply(om *OrderedMap) *OrderedMap {
+	res := NewOrderedMap()
+	for _, k := range om.Keys() {
+		sub, ok := pr[k]
+		if !ok {
+			continue
+		}
+		v, _ := om.Get(k)
+		if sub != nil {
+			child, isObj := v.(*OrderedMap)
+			if !isObj {
+				continue
+			}
+			v = sub.apply(child)
+		}
+		res.Set(k, v)
+	}
+	return res
+}
+
+// Project reads json objects from srcPath and writes to dstPath only the fields listed in keep.
+// The order of the fields is preserved. Objects are written even if none of the fields is found.
+func (p Projector) Project(srcPath, dstPath string, keep []string, ext ...string) error {
+	pr := p.projection(keep)
+	w, err := NewWriter(dstPath)
+	if err != nil {
+		return err
+	}
+	err = forEachRaw(srcPath, ext, func(raw json.RawMessage) error {
+		v, err := decodeOrdered(raw)
+		if err != nil {
+			return err
+		}
+		om, ok := v.(*OrderedMap)
+		if !ok {
+			return fmt.Errorf("cannot project %s, not an object", raw)
+		}
+		return w.Write(pr.apply(om))
+	})
+	if err != nil {
+		w.Close()
+		return err
+	}
+	return w.Close()
+}
@@ -0,0 +1,61 @@
+// Copyright (c) 2015 AKUALAB INC., All rights reserved.
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ju
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestProject(t *testing.T) {
+
+	dir := filepath.Join(os.TempDir(), "project")
+	os.RemoveAll(dir)
+	src := filepath.Join(dir, "src.json")
+	e := WriteAll(src, []json.RawMessage{
+		json.RawMessage(`{"id":1,"email":"a@b.c","user":{"name":"a","ssn":"x","addr":{"city":"c","zip":"z"}},"n":1.50}`),
+		json.RawMessage(`{"email":"d@e.f","user":"none"}`),
+	})
+	if e != nil {
+		t.Fatal(e)
+	}
+
+	dst := filepath.Join(dir, "out", "top.json")
+	e = Project(src, dst, []string{"n", "id", "user.name"})
+	if e != nil {
+		t.Fatal(e)
+	}
+	data, e := os.ReadFile(dst)
+	if e != nil {
+		t.Fatal(e)
+	}
+	expected := "{\"id\":1,\"n\":1.50}\n{}\n"
+	if string(data) != expected {
+		t.Fatalf("expected %q, got %q", expected, data)
+	}
+
+	dst = filepath.Join(dir, "out", "nested.json")
+	for _, keep := range [][]string{{"user.name", "user"}, {"user", "user.addr.city"}} {
+		pr := Projector{Nested: true}.projection(keep)
+		if sub, ok := pr["user"]; !ok || sub != nil {
+			t.Fatalf("%v: expected to keep the whole user object, got %v", keep, pr)
+		}
+	}
+	e = Projector{Nested: true}.Project(src, dst, []string{"id", "user.name", "user.addr.city"})
+	if e != nil {
+		t.Fatal(e)
+	}
+	data, e = os.ReadFile(dst)
+	if e != nil {
+		t.Fatal(e)
+	}
+	expected = "{\"id\":1,\"user\":{\"name\":\"a\",\"addr\":{\"city\":\"c\"}}}\n{}\n"
+	if string(data) != expected {
+		t.Fatalf("expected %q, got %q", expected, data)
+	}
+}
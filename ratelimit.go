@@ -0,0 +1,108 @@
+// Copyright (c) 2015 AKUALAB INC., All rights reserved.
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ju
+
+import (
+	"context"
+	"io"
+	"sync"
+	"time"
+)
+
+// WithRateLimit limits the rate at which streamers read files to bytesPerSec. The limit applies
+// to the bytes read from the files, before decompression, and is shared by all the files read
+// with the same options, including the workers of ReadJSONParallel. Up to one second of data
+// may be read in a burst. Waits are interrupted when the context set with WithContext, or
+// the context passed to ReadJSONParallelContext, is cancelled. Entries of zip and tar archives
+// are not limited. Zero means no limit.
+func WithRateLimit(bytesPerSec int64) Option {
+	return func(o *options) {
+		o.rate = nil
+		if bytesPerSec > 0 {
+			o.rate = newRateLimiter(bytesPerSec)
+		}
+	}
+}
+
+// WithContext sets a context for streamers. Cancelling it interrupts waits such as those
+// of WithRateLimit, and the read returns the context error. ParallelStream and ParallelBatches
+// also stop reading files when it is cancelled.
+func WithContext(ctx context.Context) Option {
+	return func(o *options) {
+		o.ctx = ctx
+	}
+}
+
+// rateLimiter is a token bucket that holds up to one second of tokens.
+type rateLimiter struct {
+	mu     sync.Mutex
+	rate   float64 // tokens per second
+	tokens float64
+	last   time.Time
+}
+
+func newRateLimiter(bytesPerSec int64) *rateLimiter {
+	return &rateLimiter{rate: float64(bytesPerSec), tokens: float64(bytesPerSec), last: time.Now()}
+}
+
+// wait takes n tokens, waiting until the bucket is no longer in debt or ctx is done.
+func (l *rateLimiter) wait(ctx context.Context, n int) error {
+	l.mu.Lock()
+	now := time.Now()
+	l.tokens += now.Sub(l.last).Seconds() * l.rate
+	if l.tokens > l.rate {
+		l.tokens = l.rate
+	}
+	l.last = now
+	l.tokens -= float64(n)
+	debt := l.tokens
+	l.mu.Unlock()
+	if debt >= 0 {
+		return nil
+	}
+	t := time.NewTimer(time.Duration(-debt / l.rate * float64(time.Second)))
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// refund returns n unused tokens.
+func (l *rateLimiter) refund(n int) {
+	l.mu.Lock()
+	l.tokens += float64(n)
+	l.mu.Unlock()
+}
+
+// rateReader limits the rate at which a file is read.
+type rateReader struct {
+	rc  io.ReadCloser
+	l   *rateLimiter
+	ctx context.Context
+}
+
+func (r *rateReader) Read(p []byte) (int, error) {
+	// Small reads keep the rate smooth.
+	if len(p) > int(r.l.rate) {
+		p = p[:int(r.l.rate)]
+	}
+	// Wait before reading: json.Decoder ignores an error returned with data.
+	err := r.l.wait(r.ctx, len(p))
+	if err != nil {
+		r.l.refund(len(p))
+		return 0, err
+	}
+	n, err := r.rc.Read(p)
+	r.l.refund(len(p) - n)
+	return n, err
+}
+
+func (r *rateReader) Close() error {
+	return r.rc.Close()
+}
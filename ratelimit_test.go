@@ -0,0 +1,90 @@
+// Copyright (c) 2015 AKUALAB INC., All rights reserved.
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ju
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestRateLimit(t *testing.T) {
+
+	fn := filepath.Join(os.TempDir(), "ratelimit", "r.json")
+	e := os.MkdirAll(filepath.Dir(fn), 0777)
+	if e != nil {
+		t.Fatal(e)
+	}
+	data := bytes.Repeat([]byte("{\"N\":1}\n"), 30000) // 240,000 bytes
+	e = os.WriteFile(fn, data, 0644)
+	if e != nil {
+		t.Fatal(e)
+	}
+
+	// The first 160,000 bytes are a burst, the rest takes 0.5s.
+	start := time.Now()
+	r, err := NewFileStreamer(fn, nil, WithRateLimit(160000))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := io.ReadAll(r)
+	r.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, data) {
+		t.Fatal("data does not match")
+	}
+	if d := time.Since(start); d < 400*time.Millisecond {
+		t.Fatalf("expected the read to take at least 0.4s, took %v", d)
+	}
+
+	// Cancelling the context interrupts the wait.
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	start = time.Now()
+	js, err := NewJSONStreamer(fn, WithRateLimit(1000), WithContext(ctx))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer js.Close()
+	for {
+		var x tt
+		e = js.Next(&x)
+		if e != nil {
+			break
+		}
+	}
+	if !errors.Is(e, context.DeadlineExceeded) {
+		t.Fatalf("expected context.DeadlineExceeded, got %v", e)
+	}
+	if d := time.Since(start); d > 5*time.Second {
+		t.Fatalf("expected the wait to be interrupted, took %v", d)
+	}
+
+	// Counting is not limited.
+	start = time.Now()
+	js, err = NewJSONStreamer(fn, WithRateLimit(160000))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer js.Close()
+	n, err := js.EstimateCount()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 30000 {
+		t.Fatalf("expected 30000 objects, got %d", n)
+	}
+	if d := time.Since(start); d > 300*time.Millisecond {
+		t.Fatalf("expected counting not to be limited, took %v", d)
+	}
+}
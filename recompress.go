@@ -0,0 +1,58 @@
+// Copyright (c) 2015 AKUALAB INC., All rights reserved.
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ju
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+
+	gzip "github.com/klauspost/pgzip"
+)
+
+// Recompress copies the content of file src to file dst, decompressing src if its extension
+// is ".gz" and compressing dst if its extension is ".gz". The data is copied byte by byte,
+// without decoding the json. Returns the sizes of src and dst in bytes.
+func Recompress(src, dst string) (inSize, outSize int64, err error) {
+
+	fi, err := os.Stat(src)
+	if err != nil {
+		return 0, 0, err
+	}
+	r, err := streamFile(src, newOptions())
+	if err != nil {
+		return 0, 0, err
+	}
+	defer r.Close()
+
+	f, err := newOptions().create(dst)
+	if err != nil {
+		return 0, 0, err
+	}
+	var w io.Writer = f
+	var gz *gzip.Writer
+	if filepath.Ext(dst) == ".gz" {
+		gz = gzip.NewWriter(f)
+		w = gz
+	}
+	_, err = io.Copy(w, r)
+	if err == nil && gz != nil {
+		err = gz.Close()
+	}
+	if err != nil {
+		f.Close()
+		return 0, 0, err
+	}
+	err = f.Close()
+	if err != nil {
+		return 0, 0, err
+	}
+	fo, err := os.Stat(dst)
+	if err != nil {
+		return 0, 0, err
+	}
+	return fi.Size(), fo.Size(), nil
+}
@@ -0,0 +1,56 @@
+// Copyright (c) 2015 AKUALAB INC., All rights reserved.
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ju
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRecompress(t *testing.T) {
+
+	dir := filepath.Join(os.TempDir(), "recompress")
+	os.RemoveAll(dir)
+	plain := filepath.Join(dir, "a.json")
+	var objs []tt
+	for i := 0; i < 100; i++ {
+		objs = append(objs, tt{Name: "recompress", N: i})
+	}
+	e := WriteAll(plain, objs)
+	if e != nil {
+		t.Fatal(e)
+	}
+	ref, e := os.ReadFile(plain)
+	if e != nil {
+		t.Fatal(e)
+	}
+
+	gz := filepath.Join(dir, "out", "a.json.gz")
+	in, out, err := Recompress(plain, gz)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if in != int64(len(ref)) || out <= 0 || out >= in {
+		t.Fatalf("unexpected sizes %d -> %d", in, out)
+	}
+	back := filepath.Join(dir, "out", "b.json")
+	in2, out2, err := Recompress(gz, back)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if in2 != out || out2 != in {
+		t.Fatalf("unexpected sizes %d -> %d", in2, out2)
+	}
+	data, e := os.ReadFile(back)
+	if e != nil {
+		t.Fatal(e)
+	}
+	if !bytes.Equal(data, ref) {
+		t.Fatal("content changed after round trip")
+	}
+}
@@ -0,0 +1,79 @@
+// Copyright (c) 2015 AKUALAB INC., All rights reserved.
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ju
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+)
+
+// WithRecover makes a JSONStreamer skip corrupt records instead of failing. Each file is read
+// line by line and each line is decoded separately; a line with a json syntax error is skipped
+// and logged as a warning. Use JSONStreamer.Skipped to get the number of bytes skipped.
+// Only works for newline-delimited input (one json value per line). Values that span multiple
+// lines or lines with several values cannot be decoded in this mode.
+func WithRecover() Option {
+	return func(o *options) {
+		o.recover = true
+	}
+}
+
+// Skipped returns the number of bytes of corrupt records skipped by a JSONStreamer created
+// with WithRecover.
+func (js *JSONStreamer) Skipped() int64 {
+	return js.skipped
+}
+
+// lineDecoder decodes one json value per line, skipping lines with syntax errors.
+type lineDecoder struct {
+	js   *JSONStreamer
+	br   *bufio.Reader
+	name string
+	line int
+	off  int64 // bytes read so far
+}
+
+func (js *JSONStreamer) newLineDecoder(r io.Reader) *lineDecoder {
+	var name string
+	if js.m != nil {
+		name = js.m.name
+	}
+	return &lineDecoder{js: js, br: bufio.NewReader(r), name: name}
+}
+
+func (d *lineDecoder) Decode(v interface{}) error {
+	for {
+		line, err := d.br.ReadBytes('\n')
+		if err != nil && err != io.EOF {
+			return err
+		}
+		if len(line) > 0 {
+			d.line++
+		}
+		d.off += int64(len(line))
+		data := bytes.TrimSpace(line)
+		if len(data) > 0 {
+			e := DefaultCodec.Unmarshal(data, v)
+			var se *json.SyntaxError
+			if !errors.As(e, &se) {
+				return e
+			}
+			d.js.skipped += int64(len(line))
+			d.js.opts.logger.Warn("skipping corrupt record", "path", d.name, "line", d.line, "bytes", len(line), "error", e)
+		}
+		if err == io.EOF {
+			return io.EOF
+		}
+	}
+}
+
+// InputOffset returns the offset of the end of the last line read.
+func (d *lineDecoder) InputOffset() int64 {
+	return d.off
+}
@@ -0,0 +1,62 @@
+// Copyright (c) 2015 AKUALAB INC., All rights reserved.
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ju
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRecover(t *testing.T) {
+
+	fn := filepath.Join(os.TempDir(), "recover", "r.json")
+	e := os.MkdirAll(filepath.Dir(fn), 0777)
+	if e != nil {
+		t.Fatal(e)
+	}
+	data := "{\"N\":1}\n{\"N\":2,\"Na\n\n{\"N\":3}\n}}garbage\n{\"N\":4}"
+	e = os.WriteFile(fn, []byte(data), 0644)
+	if e != nil {
+		t.Fatal(e)
+	}
+
+	js, err := NewJSONStreamer(fn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var o tt
+	js.Next(&o)
+	e = js.Next(&o)
+	if e == nil || e == Done {
+		t.Fatalf("expected syntax error, got %v", e)
+	}
+	js.Close()
+
+	js, err = NewJSONStreamer(fn, WithRecover())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer js.Close()
+	var ns []int
+	for {
+		var o tt
+		e := js.Next(&o)
+		if e == Done {
+			break
+		}
+		if e != nil {
+			t.Fatal(e)
+		}
+		ns = append(ns, o.N)
+	}
+	if len(ns) != 3 || ns[0] != 1 || ns[1] != 3 || ns[2] != 4 {
+		t.Fatalf("unexpected objects: %v", ns)
+	}
+	if js.Skipped() != int64(len("{\"N\":2,\"Na\n}}garbage\n")) {
+		t.Fatalf("unexpected skipped bytes: %d", js.Skipped())
+	}
+}
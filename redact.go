@@ -0,0 +1,57 @@
+// Copyright (c) 2015 AKUALAB INC., All rights reserved.
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ju
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// RedactMask is the value used by Redact when mask is nil.
+var RedactMask = json.RawMessage(`"***"`)
+
+// Redact reads json objects from srcPath and writes them to dstPath with the values of the
+// top level fields listed in fields replaced by mask. The mask function receives the original
+// value and returns the replacement, which must be valid json. If mask is nil, values are
+// replaced with RedactMask. The order of the fields is preserved.
+// See FileStreamer for srcPath and ext, and NewWriter for dstPath.
+func Redact(srcPath, dstPath string, fields []string, mask func(json.RawMessage) json.RawMessage, ext ...string) error {
+
+	if mask == nil {
+		mask = func(json.RawMessage) json.RawMessage { return RedactMask }
+	}
+	w, err := NewWriter(dstPath)
+	if err != nil {
+		return err
+	}
+	err = forEachRaw(srcPath, ext, func(raw json.RawMessage) error {
+		v, err := decodeOrdered(raw)
+		if err != nil {
+			return err
+		}
+		om, ok := v.(*OrderedMap)
+		if !ok {
+			return fmt.Errorf("cannot redact %s, not an object", raw)
+		}
+		for _, f := range fields {
+			x, ok := om.Get(f)
+			if !ok {
+				continue
+			}
+			data, err := DefaultCodec.Marshal(x)
+			if err != nil {
+				return err
+			}
+			om.Set(f, mask(data))
+		}
+		return w.Write(om)
+	})
+	if err != nil {
+		w.Close()
+		return err
+	}
+	return w.Close()
+}
@@ -0,0 +1,57 @@
+// Copyright (c) 2015 AKUALAB INC., All rights reserved.
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ju
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRedact(t *testing.T) {
+
+	dir := filepath.Join(os.TempDir(), "redact")
+	os.RemoveAll(dir)
+	src := filepath.Join(dir, "src.json")
+	e := WriteAll(src, []json.RawMessage{
+		json.RawMessage(`{"id":1,"email":"a@b.c","phone":{"home":"123"}}`),
+		json.RawMessage(`{"id":2}`),
+	})
+	if e != nil {
+		t.Fatal(e)
+	}
+
+	dst := filepath.Join(dir, "out", "default.json")
+	e = Redact(src, dst, []string{"email", "phone"}, nil)
+	if e != nil {
+		t.Fatal(e)
+	}
+	data, e := os.ReadFile(dst)
+	if e != nil {
+		t.Fatal(e)
+	}
+	expected := "{\"id\":1,\"email\":\"***\",\"phone\":\"***\"}\n{\"id\":2}\n"
+	if string(data) != expected {
+		t.Fatalf("expected %q, got %q", expected, data)
+	}
+
+	dst = filepath.Join(dir, "out", "mask.json")
+	e = Redact(src, dst, []string{"email"}, func(v json.RawMessage) json.RawMessage {
+		return json.RawMessage(`{"len":` + string(rune('0'+len(v))) + `}`)
+	})
+	if e != nil {
+		t.Fatal(e)
+	}
+	data, e = os.ReadFile(dst)
+	if e != nil {
+		t.Fatal(e)
+	}
+	expected = "{\"id\":1,\"email\":{\"len\":7},\"phone\":{\"home\":\"123\"}}\n{\"id\":2}\n"
+	if string(data) != expected {
+		t.Fatalf("expected %q, got %q", expected, data)
+	}
+}
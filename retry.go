@@ -0,0 +1,134 @@
+// Copyright (c) 2015 AKUALAB INC., All rights reserved.
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ju
+
+import (
+	"errors"
+	"io"
+	"os"
+	"time"
+)
+
+// WithRetry retries opening and reading a file up to attempts times when an error that may be
+// transient occurs, such as an I/O error on a network filesystem. After a read error, the file
+// is reopened and reading resumes at the same offset. The wait between attempts starts at
+// backoff and doubles after each attempt. End of file, missing files, permission errors,
+// and decode errors are not retried. Each retry is logged as a warning. The wait is
+// interrupted when the context set with WithContext is done.
+func WithRetry(attempts int, backoff time.Duration) Option {
+	return func(o *options) {
+		o.retries = attempts
+		o.backoff = backoff
+	}
+}
+
+// openSeeker opens files read by retryFile. Replaced in tests.
+var openSeeker = func(path string) (io.ReadSeekCloser, error) {
+	return os.Open(path)
+}
+
+// retryable returns true if err may be transient.
+func retryable(err error) bool {
+	return err != nil && err != io.EOF && !errors.Is(err, os.ErrNotExist) &&
+		!errors.Is(err, os.ErrPermission) && !errors.Is(err, os.ErrClosed)
+}
+
+// retry calls fn until it succeeds, it returns an error that is not retryable,
+// or the attempts are exhausted. The wait between attempts stops when the context
+// of the options is done, and the context error is returned.
+func (o *options) retry(op, path string, fn func() error) error {
+	ctx := o.context()
+	err := fn()
+	for i := 0; i < o.retries && retryable(err); i++ {
+		wait := o.backoff << i
+		o.logger.Warn("retrying", "op", op, "path", path, "attempt", i+1, "wait", wait, "error", err)
+		t := time.NewTimer(wait)
+		select {
+		case <-t.C:
+		case <-ctx.Done():
+			t.Stop()
+			return ctx.Err()
+		}
+		err = fn()
+	}
+	return err
+}
+
+// openFile opens path for reading. If retries are enabled, the returned file
+// retries failed opens and reads.
+func openFile(path string, o *options) (io.ReadCloser, error) {
+	if o.retries < 1 {
+		return os.Open(path)
+	}
+	rf := &retryFile{path: path, o: o}
+	err := o.retry("open", path, rf.open)
+	if err != nil {
+		return nil, err
+	}
+	return rf, nil
+}
+
+// retryFile reopens the file and seeks to the current offset when a read fails.
+type retryFile struct {
+	path string
+	o    *options
+	f    io.ReadSeekCloser
+	off  int64
+}
+
+func (rf *retryFile) open() error {
+	if rf.f != nil {
+		rf.f.Close()
+		rf.f = nil
+	}
+	f, err := openSeeker(rf.path)
+	if err != nil {
+		return err
+	}
+	if rf.off > 0 {
+		_, err = f.Seek(rf.off, io.SeekStart)
+		if err != nil {
+			f.Close()
+			return err
+		}
+	}
+	rf.f = f
+	return nil
+}
+
+func (rf *retryFile) Read(p []byte) (int, error) {
+	var n int
+	err := rf.o.retry("read", rf.path, func() error {
+		if rf.f == nil {
+			err := rf.open()
+			if err != nil {
+				return err
+			}
+		}
+		var err error
+		n, err = rf.f.Read(p)
+		rf.off += int64(n)
+		if n > 0 && err != nil && err != io.EOF {
+			// Return the data now, the next Read reopens the file.
+			rf.f.Close()
+			rf.f = nil
+			return nil
+		}
+		if retryable(err) {
+			rf.f.Close()
+			rf.f = nil
+		}
+		return err
+	})
+	return n, err
+}
+
+func (rf *retryFile) Close() error {
+	if rf.f == nil {
+		return nil
+	}
+	return rf.f.Close()
+}
@@ -0,0 +1,118 @@
+// Copyright (c) 2015 AKUALAB INC., All rights reserved.
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ju
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+var errFlaky = errors.New("flaky")
+
+// flakyFile fails every other read.
+type flakyFile struct {
+	*os.File
+	reads *int
+}
+
+func (f flakyFile) Read(p []byte) (int, error) {
+	*f.reads++
+	if *f.reads%2 == 0 {
+		return 0, errFlaky
+	}
+	if len(p) > 16 {
+		p = p[:16]
+	}
+	return f.File.Read(p)
+}
+
+func TestRetry(t *testing.T) {
+
+	fn := filepath.Join(os.TempDir(), "retry", "r.json.gz")
+	var ref []tt
+	for i := 0; i < 50; i++ {
+		ref = append(ref, tt{Name: "retry", N: i})
+	}
+	e := WriteAll(fn, ref)
+	if e != nil {
+		t.Fatal(e)
+	}
+
+	opens, reads := 0, 0
+	openSeeker = func(path string) (io.ReadSeekCloser, error) {
+		opens++
+		if opens == 1 {
+			return nil, errFlaky
+		}
+		f, err := os.Open(path)
+		return flakyFile{File: f, reads: &reads}, err
+	}
+	defer func() {
+		openSeeker = func(path string) (io.ReadSeekCloser, error) { return os.Open(path) }
+	}()
+
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, nil))
+	js, err := NewJSONStreamer(fn, WithRetry(2, time.Millisecond), WithLogger(logger))
+	if err != nil {
+		t.Fatal(err)
+	}
+	n := 0
+	for {
+		var o tt
+		e := js.Next(&o)
+		if e == Done {
+			break
+		}
+		if e != nil {
+			t.Fatal(e)
+		}
+		if !ref[n].equal(o) {
+			t.Fatalf("expected %v, got %v", ref[n], o)
+		}
+		n++
+	}
+	js.Close()
+	if n != len(ref) {
+		t.Fatalf("expected %d objects, got %d", len(ref), n)
+	}
+	if !strings.Contains(buf.String(), "retrying") || !strings.Contains(buf.String(), "op=open") {
+		t.Fatalf("expected retries in the log, got %q", buf.String())
+	}
+
+	// Errors that are not transient are not retried.
+	openSeeker = func(path string) (io.ReadSeekCloser, error) { return os.Open(path) }
+	_, err = openFile(filepath.Join(os.TempDir(), "retry", "missing.json"), newOptions(WithRetry(3, time.Hour)))
+	if !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("expected not exist error, got %v", err)
+	}
+}
+
+func TestRetryCanceled(t *testing.T) {
+
+	openSeeker = func(path string) (io.ReadSeekCloser, error) { return nil, errFlaky }
+	defer func() {
+		openSeeker = func(path string) (io.ReadSeekCloser, error) { return os.Open(path) }
+	}()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	_, err := openFile(filepath.Join(os.TempDir(), "retry", "r.json.gz"), newOptions(WithRetry(3, time.Hour), WithContext(ctx)))
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected deadline exceeded, got %v", err)
+	}
+	if time.Since(start) > time.Minute {
+		t.Fatal("expected the wait to be interrupted")
+	}
+}
@@ -0,0 +1,41 @@
+// Copyright (c) 2015 AKUALAB INC., All rights reserved.
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ju
+
+import (
+	"encoding/json"
+	"math/rand"
+)
+
+// ReservoirSample returns a uniform random sample of k json objects from srcPath, reading
+// the files once (Algorithm R). Only the sample is kept in memory. The sample is the same for
+// the same seed and data. If there are fewer than k objects, all of them are returned.
+// The objects are returned in the order of the reservoir, not in the order they were read.
+// See FileStreamer for srcPath and ext.
+func ReservoirSample(srcPath string, k int, seed int64, ext ...string) ([]json.RawMessage, error) {
+
+	if k <= 0 {
+		return nil, nil
+	}
+	rng := rand.New(rand.NewSource(seed))
+	sample := make([]json.RawMessage, 0, k)
+	var n int64
+	err := forEachRaw(srcPath, ext, func(raw json.RawMessage) error {
+		n++
+		if len(sample) < k {
+			sample = append(sample, raw)
+			return nil
+		}
+		if j := rng.Int63n(n); j < int64(k) {
+			sample[j] = raw
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return sample, nil
+}
@@ -0,0 +1,76 @@
+// Copyright (c) 2015 AKUALAB INC., All rights reserved.
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ju
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestReservoirSample(t *testing.T) {
+
+	fn := filepath.Join(os.TempDir(), "sample", "data.json.gz")
+	data := make([]tt, 1000)
+	for i := range data {
+		data[i].N = i
+	}
+	e := WriteAll(fn, data)
+	if e != nil {
+		t.Fatal(e)
+	}
+
+	a, err := ReservoirSample(fn, 10, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := ReservoirSample(fn, 10, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(a) != 10 || fmt.Sprintf("%s", a) != fmt.Sprintf("%s", b) {
+		t.Fatalf("expected the same 10 objects for the same seed, got %s and %s", a, b)
+	}
+	seen := map[int]bool{}
+	for _, raw := range a {
+		var x tt
+		e := json.Unmarshal(raw, &x)
+		if e != nil {
+			t.Fatal(e)
+		}
+		if seen[x.N] {
+			t.Fatalf("object %d sampled twice", x.N)
+		}
+		seen[x.N] = true
+	}
+
+	// The last objects must be sampled as often as the first ones.
+	counts := make([]int, 2)
+	for seed := int64(0); seed < 200; seed++ {
+		s, err := ReservoirSample(fn, 100, seed)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, raw := range s {
+			var x tt
+			json.Unmarshal(raw, &x)
+			counts[x.N*2/len(data)]++
+		}
+	}
+	if counts[0] < 8000 || counts[1] < 8000 {
+		t.Fatalf("sample is biased: %v", counts)
+	}
+
+	all, err := ReservoirSample(fn, 2000, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(all) != len(data) {
+		t.Fatalf("expected %d objects, got %d", len(data), len(all))
+	}
+}
@@ -0,0 +1,430 @@
+// Copyright (c) 2015 AKUALAB INC., All rights reserved.
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ju
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"regexp"
+	"strconv"
+	"unicode/utf8"
+)
+
+// Schema is a compiled JSON Schema. It supports a subset of the specification
+// that covers the most common validations:
+//
+//	type, enum, const
+//	properties, required, additionalProperties
+//	items, minItems, maxItems
+//	minimum, maximum, exclusiveMinimum, exclusiveMaximum (numeric form)
+//	minLength, maxLength, pattern
+//	allOf, anyOf, oneOf, not
+//
+// The annotation keywords $schema, $id, $comment, $defs, definitions, title, description,
+// default, examples, format, deprecated, readOnly, and writeOnly are accepted and ignored.
+// CompileSchema returns an error for any other keyword, including $ref and patternProperties,
+// so that a schema is never silently weaker than intended.
+type Schema struct {
+	types        []string
+	enum         []interface{}
+	konst        interface{}
+	hasConst     bool
+	properties   map[string]*Schema
+	required     []string
+	additional   *Schema
+	noAdditional bool
+	items        *Schema
+	minItems     *int
+	maxItems     *int
+	minimum      *float64
+	maximum      *float64
+	exclMinimum  *float64
+	exclMaximum  *float64
+	minLength    *int
+	maxLength    *int
+	pattern      *regexp.Regexp
+	allOf        []*Schema
+	anyOf        []*Schema
+	oneOf        []*Schema
+	not          *Schema
+	never        bool // the schema is false
+}
+
+// CompileSchema parses a JSON Schema.
+func CompileSchema(schemaJSON []byte) (*Schema, error) {
+	v, err := decodeNumbers(schemaJSON)
+	if err != nil {
+		return nil, fmt.Errorf("schema: %w", err)
+	}
+	return compileSchema(v, "#")
+}
+
+// Validate returns an error wrapping ErrInvalid if raw does not conform to the schema.
+func (s *Schema) Validate(raw json.RawMessage) error {
+	v, err := decodeNumbers(raw)
+	if err != nil {
+		return err
+	}
+	return s.validate(v, "")
+}
+
+func decodeNumbers(data []byte) (interface{}, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var v interface{}
+	err := dec.Decode(&v)
+	return v, err
+}
+
+func compileSchema(v interface{}, loc string) (*Schema, error) {
+	s := &Schema{}
+	switch t := v.(type) {
+	case bool:
+		s.never = !t
+		return s, nil
+	case map[string]interface{}:
+	default:
+		return nil, fmt.Errorf("schema %s: must be an object or a boolean", loc)
+	}
+	m := v.(map[string]interface{})
+	var err error
+	for k, x := range m {
+		kloc := loc + "/" + k
+		switch k {
+		case "type":
+			switch t := x.(type) {
+			case string:
+				s.types = []string{t}
+			case []interface{}:
+				for _, y := range t {
+					str, ok := y.(string)
+					if !ok {
+						return nil, fmt.Errorf("schema %s: must be a string or an array of strings", kloc)
+					}
+					s.types = append(s.types, str)
+				}
+			default:
+				return nil, fmt.Errorf("schema %s: must be a string or an array of strings", kloc)
+			}
+		case "enum":
+			a, ok := x.([]interface{})
+			if !ok {
+				return nil, fmt.Errorf("schema %s: must be an array", kloc)
+			}
+			s.enum = a
+		case "const":
+			s.konst = x
+			s.hasConst = true
+		case "properties":
+			pm, ok := x.(map[string]interface{})
+			if !ok {
+				return nil, fmt.Errorf("schema %s: must be an object", kloc)
+			}
+			s.properties = map[string]*Schema{}
+			for name, p := range pm {
+				s.properties[name], err = compileSchema(p, kloc+"/"+name)
+				if err != nil {
+					return nil, err
+				}
+			}
+		case "required":
+			a, ok := x.([]interface{})
+			if !ok {
+				return nil, fmt.Errorf("schema %s: must be an array", kloc)
+			}
+			for _, y := range a {
+				str, ok := y.(string)
+				if !ok {
+					return nil, fmt.Errorf("schema %s: must be an array of strings", kloc)
+				}
+				s.required = append(s.required, str)
+			}
+		case "additionalProperties":
+			if b, ok := x.(bool); ok {
+				s.noAdditional = !b
+				continue
+			}
+			s.additional, err = compileSchema(x, kloc)
+		case "items":
+			if _, ok := x.([]interface{}); ok {
+				return nil, fmt.Errorf("schema %s: tuple form is not supported", kloc)
+			}
+			s.items, err = compileSchema(x, kloc)
+		case "minItems":
+			s.minItems, err = schemaInt(x, kloc)
+		case "maxItems":
+			s.maxItems, err = schemaInt(x, kloc)
+		case "minLength":
+			s.minLength, err = schemaInt(x, kloc)
+		case "maxLength":
+			s.maxLength, err = schemaInt(x, kloc)
+		case "minimum":
+			s.minimum, err = schemaFloat(x, kloc)
+		case "maximum":
+			s.maximum, err = schemaFloat(x, kloc)
+		case "exclusiveMinimum":
+			s.exclMinimum, err = schemaFloat(x, kloc)
+		case "exclusiveMaximum":
+			s.exclMaximum, err = schemaFloat(x, kloc)
+		case "pattern":
+			str, ok := x.(string)
+			if !ok {
+				return nil, fmt.Errorf("schema %s: must be a string", kloc)
+			}
+			s.pattern, err = regexp.Compile(str)
+		case "allOf", "anyOf", "oneOf":
+			a, ok := x.([]interface{})
+			if !ok {
+				return nil, fmt.Errorf("schema %s: must be an array", kloc)
+			}
+			var list []*Schema
+			for i, y := range a {
+				sub, err := compileSchema(y, kloc+"/"+strconv.Itoa(i))
+				if err != nil {
+					return nil, err
+				}
+				list = append(list, sub)
+			}
+			switch k {
+			case "allOf":
+				s.allOf = list
+			case "anyOf":
+				s.anyOf = list
+			default:
+				s.oneOf = list
+			}
+		case "not":
+			s.not, err = compileSchema(x, kloc)
+		default:
+			if !schemaAnnotations[k] {
+				return nil, fmt.Errorf("schema %s: unsupported keyword", kloc)
+			}
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return s, nil
+}
+
+// schemaAnnotations are the keywords that do not affect validation.
+var schemaAnnotations = map[string]bool{
+	"$schema": true, "$id": true, "$comment": true, "$defs": true, "definitions": true,
+	"title": true, "description": true, "default": true, "examples": true, "format": true,
+	"deprecated": true, "readOnly": true, "writeOnly": true,
+}
+
+func schemaInt(v interface{}, loc string) (*int, error) {
+	n, ok := v.(json.Number)
+	if ok {
+		i, err := n.Int64()
+		if err == nil && i >= 0 {
+			x := int(i)
+			return &x, nil
+		}
+	}
+	return nil, fmt.Errorf("schema %s: must be a non-negative integer", loc)
+}
+
+func schemaFloat(v interface{}, loc string) (*float64, error) {
+	n, ok := v.(json.Number)
+	if ok {
+		f, err := n.Float64()
+		if err == nil {
+			return &f, nil
+		}
+	}
+	return nil, fmt.Errorf("schema %s: must be a number", loc)
+}
+
+// jsonType returns the JSON Schema type name of a decoded value.
+func jsonType(v interface{}) string {
+	switch t := v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case string:
+		return "string"
+	case json.Number:
+		if _, err := t.Int64(); err == nil {
+			return "integer"
+		}
+		if f, err := t.Float64(); err == nil && f == math.Trunc(f) && !math.IsInf(f, 0) {
+			return "integer"
+		}
+		return "number"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	}
+	return fmt.Sprintf("%T", v)
+}
+
+func invalid(loc, format string, args ...interface{}) error {
+	if loc == "" {
+		loc = "/"
+	}
+	return fmt.Errorf("%w: %s: %s", ErrInvalid, loc, fmt.Sprintf(format, args...))
+}
+
+func (s *Schema) validate(v interface{}, loc string) error {
+	if s.never {
+		return invalid(loc, "not allowed")
+	}
+	vt := jsonType(v)
+	if len(s.types) > 0 {
+		ok := false
+		for _, t := range s.types {
+			if t == vt || (t == "number" && vt == "integer") {
+				ok = true
+				break
+			}
+		}
+		if !ok {
+			return invalid(loc, "expected %v, got %s", s.types, vt)
+		}
+	}
+	if s.enum != nil {
+		ok := false
+		for _, e := range s.enum {
+			if jsonEqual(e, v) {
+				ok = true
+				break
+			}
+		}
+		if !ok {
+			return invalid(loc, "value not in enum")
+		}
+	}
+	if s.hasConst && !jsonEqual(s.konst, v) {
+		return invalid(loc, "value does not match const")
+	}
+
+	switch t := v.(type) {
+	case map[string]interface{}:
+		for _, name := range s.required {
+			if _, ok := t[name]; !ok {
+				return invalid(loc, "missing required property %q", name)
+			}
+		}
+		for name, x := range t {
+			ploc := loc + "/" + name
+			if ps, ok := s.properties[name]; ok {
+				err := ps.validate(x, ploc)
+				if err != nil {
+					return err
+				}
+				continue
+			}
+			if s.noAdditional {
+				return invalid(ploc, "additional property not allowed")
+			}
+			if s.additional != nil {
+				err := s.additional.validate(x, ploc)
+				if err != nil {
+					return err
+				}
+			}
+		}
+	case []interface{}:
+		if s.minItems != nil && len(t) < *s.minItems {
+			return invalid(loc, "expected at least %d items, got %d", *s.minItems, len(t))
+		}
+		if s.maxItems != nil && len(t) > *s.maxItems {
+			return invalid(loc, "expected at most %d items, got %d", *s.maxItems, len(t))
+		}
+		if s.items != nil {
+			for i, x := range t {
+				err := s.items.validate(x, loc+"/"+strconv.Itoa(i))
+				if err != nil {
+					return err
+				}
+			}
+		}
+	case string:
+		n := utf8.RuneCountInString(t)
+		if s.minLength != nil && n < *s.minLength {
+			return invalid(loc, "expected length >= %d, got %d", *s.minLength, n)
+		}
+		if s.maxLength != nil && n > *s.maxLength {
+			return invalid(loc, "expected length <= %d, got %d", *s.maxLength, n)
+		}
+		if s.pattern != nil && !s.pattern.MatchString(t) {
+			return invalid(loc, "does not match pattern %q", s.pattern)
+		}
+	case json.Number:
+		f, err := t.Float64()
+		if err != nil {
+			return invalid(loc, "%v", err)
+		}
+		if s.minimum != nil && f < *s.minimum {
+			return invalid(loc, "expected >= %v, got %v", *s.minimum, t)
+		}
+		if s.maximum != nil && f > *s.maximum {
+			return invalid(loc, "expected <= %v, got %v", *s.maximum, t)
+		}
+		if s.exclMinimum != nil && f <= *s.exclMinimum {
+			return invalid(loc, "expected > %v, got %v", *s.exclMinimum, t)
+		}
+		if s.exclMaximum != nil && f >= *s.exclMaximum {
+			return invalid(loc, "expected < %v, got %v", *s.exclMaximum, t)
+		}
+	}
+
+	for _, sub := range s.allOf {
+		err := sub.validate(v, loc)
+		if err != nil {
+			return err
+		}
+	}
+	if len(s.anyOf) > 0 {
+		ok := false
+		for _, sub := range s.anyOf {
+			if sub.validate(v, loc) == nil {
+				ok = true
+				break
+			}
+		}
+		if !ok {
+			return invalid(loc, "does not match anyOf")
+		}
+	}
+	if len(s.oneOf) > 0 {
+		n := 0
+		for _, sub := range s.oneOf {
+			if sub.validate(v, loc) == nil {
+				n++
+			}
+		}
+		if n != 1 {
+			return invalid(loc, "matches %d schemas in oneOf", n)
+		}
+	}
+	if s.not != nil && s.not.validate(v, loc) == nil {
+		return invalid(loc, "must not match schema in not")
+	}
+	return nil
+}
+
+// jsonEqual compares decoded json values. Numbers are compared by value.
+func jsonEqual(a, b interface{}) bool {
+	na, aok := a.(json.Number)
+	nb, bok := b.(json.Number)
+	if aok && bok {
+		fa, ea := na.Float64()
+		fb, eb := nb.Float64()
+		if ea == nil && eb == nil {
+			return fa == fb
+		}
+		return na == nb
+	}
+	return reflect.DeepEqual(a, b)
+}
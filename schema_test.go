@@ -0,0 +1,149 @@
+// Copyright (c) 2015 AKUALAB INC., All rights reserved.
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ju
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const testSchema = `{
+  "type": "object",
+  "required": ["Name", "N"],
+  "properties": {
+    "Name": {"type": "string", "minLength": 1},
+    "N": {"type": "integer", "minimum": 0},
+    "Words": {"type": "array", "items": {"type": "string"}}
+  }
+}`
+
+func TestSchema(t *testing.T) {
+
+	fn := filepath.Join(os.TempDir(), "schema", "schema.json")
+	e := os.MkdirAll(filepath.Dir(fn), 0777)
+	if e != nil {
+		t.Fatal(e)
+	}
+	data := `{"Name":"a","N":1}
+{"Name":"b","N":-1}
+{"Name":"c","N":2,"Words":["x",3]}
+{"N":3}
+{"Name":"d","N":4,"Words":["x"]}
+`
+	e = os.WriteFile(fn, []byte(data), 0644)
+	if e != nil {
+		t.Fatal(e)
+	}
+
+	// Reject mode.
+	js, err := NewJSONStreamer(fn, WithSchema([]byte(testSchema)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var o tt
+	e = js.Next(&o)
+	if e != nil {
+		t.Fatal(e)
+	}
+	e = js.Next(&o)
+	if !errors.Is(e, ErrInvalid) || !strings.Contains(e.Error(), "/N") {
+		t.Fatalf("expected validation error for /N, got %v", e)
+	}
+	e = js.Next(&o)
+	if !errors.Is(e, ErrInvalid) || !strings.Contains(e.Error(), "/Words/1") {
+		t.Fatalf("expected validation error for /Words/1, got %v", e)
+	}
+	e = js.Next(&o)
+	if !errors.Is(e, ErrInvalid) || !strings.Contains(e.Error(), `"Name"`) {
+		t.Fatalf("expected missing Name error, got %v", e)
+	}
+	js.Close()
+
+	// Skip mode.
+	js, err = NewJSONStreamer(fn, WithSchema([]byte(testSchema)), WithSkipInvalid())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for {
+		var o tt
+		e := js.Next(&o)
+		if e == Done {
+			break
+		}
+		if e != nil {
+			t.Fatal(e)
+		}
+		names = append(names, o.Name)
+	}
+	js.Close()
+	if strings.Join(names, ",") != "a,d" {
+		t.Fatalf("expected a,d, got %v", names)
+	}
+
+	_, err = NewJSONStreamer(fn, WithSchema([]byte(`{"type":`)))
+	if err == nil {
+		t.Fatal("expected schema error")
+	}
+}
+
+func TestSchemaKeywords(t *testing.T) {
+
+	cases := []struct {
+		schema, doc string
+		valid       bool
+	}{
+		{`{"enum":["a",1]}`, `1.0`, true},
+		{`{"enum":["a",1]}`, `"b"`, false},
+		{`{"const":{"a":1}}`, `{"a":1}`, true},
+		{`{"type":["string","null"]}`, `null`, true},
+		{`{"type":"number","exclusiveMaximum":3}`, `3`, false},
+		{`{"type":"string","pattern":"^x+$"}`, `"xxx"`, true},
+		{`{"additionalProperties":false,"properties":{"a":{}}}`, `{"b":1}`, false},
+		{`{"additionalProperties":{"type":"integer"}}`, `{"b":1}`, true},
+		{`{"anyOf":[{"type":"string"},{"type":"integer"}]}`, `true`, false},
+		{`{"oneOf":[{"type":"number"},{"type":"integer"}]}`, `1`, false},
+		{`{"not":{"type":"null"}}`, `0`, true},
+		{`{"maxItems":1}`, `[1,2]`, false},
+		{`false`, `1`, false},
+	}
+	for _, c := range cases {
+		s, err := CompileSchema([]byte(c.schema))
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = s.Validate([]byte(c.doc))
+		if (err == nil) != c.valid {
+			t.Fatalf("schema %s, doc %s: expected valid=%t, got %v", c.schema, c.doc, c.valid, err)
+		}
+	}
+}
+
+func TestSchemaUnsupported(t *testing.T) {
+
+	for _, c := range []struct {
+		schema, loc string
+	}{
+		{`{"properties":{"x":{"$ref":"#/$defs/n"}},"$defs":{"n":{"type":"integer"}}}`, "#/properties/x/$ref"},
+		{`{"patternProperties":{"^a":{"type":"string"}}}`, "#/patternProperties"},
+		{`{"type":"array","items":[{"type":"string"}]}`, "#/items"},
+		{`{"allOf":[{"unevaluatedProperties":false}]}`, "#/allOf/0/unevaluatedProperties"},
+	} {
+		_, err := CompileSchema([]byte(c.schema))
+		if err == nil || !strings.Contains(err.Error(), c.loc) {
+			t.Fatalf("schema %s: expected an error at %s, got %v", c.schema, c.loc, err)
+		}
+	}
+
+	// Annotations are accepted.
+	_, err := CompileSchema([]byte(`{"$schema":"https://json-schema.org/draft/2020-12/schema","title":"t","description":"d","type":"string","format":"date","default":"x"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+}
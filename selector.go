@@ -0,0 +1,143 @@
+// Copyright (c) 2015 AKUALAB INC., All rights reserved.
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ju
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ErrNoMatch is returned by Select when the path does not exist in the object.
+var ErrNoMatch = errors.New("path not found")
+
+// Select returns the subtree of raw at path. The path is a sequence of object keys
+// and array indices, such as "$.payload.items[2].id", "payload.items.2.id", or "$" for
+// the whole object. The leading "$" is optional. Returns ErrNoMatch if the path does not exist.
+func Select(raw json.RawMessage, path string) (json.RawMessage, error) {
+	steps, err := parseSelector(path)
+	if err != nil {
+		return nil, err
+	}
+	return selectSteps(raw, steps)
+}
+
+// StreamSelect reads json objects from srcPath and calls out with the subtree at path
+// for each object (see Select). Objects that do not contain the path are skipped.
+// See FileStreamer for srcPath and ext.
+func StreamSelect(srcPath string, path string, out func(json.RawMessage) error, ext ...string) error {
+	steps, err := parseSelector(path)
+	if err != nil {
+		return err
+	}
+	return forEachRaw(srcPath, ext, func(raw json.RawMessage) error {
+		v, err := selectSteps(raw, steps)
+		if err == ErrNoMatch {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		return out(v)
+	})
+}
+
+// selector step, either an object key or an array index.
+type step struct {
+	key   string
+	index int
+	isIdx bool
+}
+
+func parseSelector(path string) ([]step, error) {
+	p := strings.TrimPrefix(path, "$")
+	var steps []step
+	for len(p) > 0 {
+		switch p[0] {
+		case '.':
+			p = p[1:]
+			end := strings.IndexAny(p, ".[")
+			if end < 0 {
+				end = len(p)
+			}
+			if end == 0 {
+				return nil, fmt.Errorf("invalid path %q: empty key", path)
+			}
+			k := p[:end]
+			p = p[end:]
+			if i, err := strconv.Atoi(k); err == nil && i >= 0 {
+				steps = append(steps, step{key: k, index: i, isIdx: true})
+				continue
+			}
+			steps = append(steps, step{key: k})
+		case '[':
+			end := strings.IndexByte(p, ']')
+			if end < 0 {
+				return nil, fmt.Errorf("invalid path %q: missing ]", path)
+			}
+			k := p[1:end]
+			p = p[end+1:]
+			if uq, err := strconv.Unquote(k); err == nil {
+				steps = append(steps, step{key: uq})
+				continue
+			}
+			i, err := strconv.Atoi(k)
+			if err != nil || i < 0 {
+				return nil, fmt.Errorf("invalid path %q: bad index %q", path, k)
+			}
+			steps = append(steps, step{index: i, isIdx: true})
+		default:
+			// Path without leading "$.".
+			p = "." + p
+		}
+	}
+	return steps, nil
+}
+
+func selectSteps(raw json.RawMessage, steps []step) (json.RawMessage, error) {
+	for _, s := range steps {
+		raw = trimSpace(raw)
+		if len(raw) == 0 {
+			return nil, ErrNoMatch
+		}
+		switch raw[0] {
+		case '{':
+			var m map[string]json.RawMessage
+			err := DefaultCodec.Unmarshal(raw, &m)
+			if err != nil {
+				return nil, err
+			}
+			v, ok := m[s.key]
+			if !ok {
+				return nil, ErrNoMatch
+			}
+			raw = v
+		case '[':
+			if !s.isIdx {
+				return nil, ErrNoMatch
+			}
+			var a []json.RawMessage
+			err := DefaultCodec.Unmarshal(raw, &a)
+			if err != nil {
+				return nil, err
+			}
+			if s.index >= len(a) {
+				return nil, ErrNoMatch
+			}
+			raw = a[s.index]
+		default:
+			return nil, ErrNoMatch
+		}
+	}
+	return raw, nil
+}
+
+func trimSpace(raw json.RawMessage) json.RawMessage {
+	return bytes.TrimSpace(raw)
+}
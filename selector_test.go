@@ -0,0 +1,73 @@
+// Copyright (c) 2015 AKUALAB INC., All rights reserved.
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ju
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSelect(t *testing.T) {
+
+	raw := json.RawMessage(`{"payload":{"id":7,"items":[{"a":1},{"a":[10,20]}],"a.b":true}}`)
+	cases := map[string]string{
+		"$":                     string(raw),
+		"$.payload.id":          `7`,
+		"payload.items[1].a[0]": `10`,
+		"$.payload.items.1.a.1": `20`,
+		`$.payload["a.b"]`:      `true`,
+	}
+	for path, expected := range cases {
+		v, err := Select(raw, path)
+		if err != nil {
+			t.Fatalf("%s: %v", path, err)
+		}
+		if string(v) != expected {
+			t.Fatalf("%s: expected %s, got %s", path, expected, v)
+		}
+	}
+	for _, path := range []string{"$.missing", "$.payload.items[5]", "$.payload.id.x"} {
+		_, err := Select(raw, path)
+		if err != ErrNoMatch {
+			t.Fatalf("%s: expected ErrNoMatch, got %v", path, err)
+		}
+	}
+	_, err := Select(raw, "$.payload[x")
+	if err == nil {
+		t.Fatal("expected invalid path error")
+	}
+}
+
+func TestStreamSelect(t *testing.T) {
+
+	fn := filepath.Join(os.TempDir(), "select", "select.json")
+	e := os.MkdirAll(filepath.Dir(fn), 0777)
+	if e != nil {
+		t.Fatal(e)
+	}
+	data := `{"payload":{"id":"a"}}
+{"other":1}
+{"payload":{"id":"b"}}
+`
+	e = os.WriteFile(fn, []byte(data), 0644)
+	if e != nil {
+		t.Fatal(e)
+	}
+	var ids []string
+	e = StreamSelect(fn, "$.payload.id", func(v json.RawMessage) error {
+		ids = append(ids, string(v))
+		return nil
+	})
+	if e != nil {
+		t.Fatal(e)
+	}
+	if strings.Join(ids, ",") != `"a","b"` {
+		t.Fatalf("unexpected %v", ids)
+	}
+}
@@ -0,0 +1,93 @@
+// Copyright (c) 2015 AKUALAB INC., All rights reserved.
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ju
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+)
+
+// WithRecordSeparator makes a Writer end each object with sep instead of a newline,
+// and makes streamers split the input on sep instead of relying on the json syntax.
+// Whitespace around each record is ignored and empty records are skipped.
+//
+// The separator must not appear in the encoded objects. Control characters other than
+// whitespace, such as the ASCII record separator 0x1E, are always safe because json
+// escapes them in strings.
+func WithRecordSeparator(sep byte) Option {
+	return func(o *options) {
+		o.recordSep = sep
+		o.hasRecordSep = true
+	}
+}
+
+// WithJSONSeq reads and writes JSON text sequences as defined in RFC 7464 (media type
+// application/json-seq). A Writer writes each object prefixed by the ASCII record separator
+// 0x1E and followed by a newline. Streamers split the input on the record separator and
+// ignore the whitespace around each record, so input without the newlines is also accepted.
+func WithJSONSeq() Option {
+	return func(o *options) {
+		o.recordSep = recordSeparator
+		o.hasRecordSep = true
+		o.jsonSeq = true
+	}
+}
+
+// recordSeparator is the ASCII record separator used by JSON text sequences.
+const recordSeparator = 0x1e
+
+// sepEncoder writes each object followed by a separator.
+// If prefix is set, it is written before each object.
+type sepEncoder struct {
+	w      io.Writer
+	prefix []byte
+	sep    byte
+	buf    []byte
+}
+
+func (e *sepEncoder) Encode(v interface{}) error {
+	data, err := DefaultCodec.Marshal(v)
+	if err != nil {
+		return err
+	}
+	e.buf = append(append(append(e.buf[:0], e.prefix...), data...), e.sep)
+	_, err = e.w.Write(e.buf)
+	return err
+}
+
+// sepDecoder decodes records delimited by a separator.
+type sepDecoder struct {
+	br  *bufio.Reader
+	sep byte
+	off int64 // bytes read so far
+}
+
+func newSepDecoder(r io.Reader, sep byte) *sepDecoder {
+	return &sepDecoder{br: bufio.NewReader(r), sep: sep}
+}
+
+func (d *sepDecoder) Decode(v interface{}) error {
+	for {
+		rec, err := d.br.ReadBytes(d.sep)
+		if err != nil && err != io.EOF {
+			return err
+		}
+		d.off += int64(len(rec))
+		data := bytes.TrimSpace(bytes.TrimSuffix(rec, []byte{d.sep}))
+		if len(data) > 0 {
+			return DefaultCodec.Unmarshal(data, v)
+		}
+		if err == io.EOF {
+			return io.EOF
+		}
+	}
+}
+
+// InputOffset returns the offset of the end of the last record read.
+func (d *sepDecoder) InputOffset() int64 {
+	return d.off
+}
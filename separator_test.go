@@ -0,0 +1,152 @@
+// Copyright (c) 2015 AKUALAB INC., All rights reserved.
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ju
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRecordSeparator(t *testing.T) {
+
+	dir := filepath.Join(os.TempDir(), "separator")
+	os.RemoveAll(dir)
+	data := []tt{{Name: "a", N: 1, Words: []string{"x", "y"}}, {Name: "b\x1e", N: 2}}
+	for _, fn := range []string{"s.json", "s.json.gz"} {
+		w, err := NewWriter(filepath.Join(dir, fn), WithRecordSeparator(0x1e))
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, v := range data {
+			err = w.Write(v)
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+		err = w.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	b, err := os.ReadFile(filepath.Join(dir, "s.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Count(b, []byte{0x1e}) != 2 || bytes.IndexByte(b, '\n') >= 0 {
+		t.Fatalf("unexpected file content: %q", b)
+	}
+
+	js, err := NewJSONStreamer(dir, WithRecordSeparator(0x1e))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer js.Close()
+	n := 0
+	for ; ; n++ {
+		var v tt
+		err := js.Next(&v)
+		if err == Done {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !v.equal(data[n%2]) {
+			t.Fatalf("expected %v, got %v", data[n%2], v)
+		}
+	}
+	if n != 4 {
+		t.Fatalf("expected 4 objects, got %d", n)
+	}
+}
+
+func TestRecordSeparatorWhitespace(t *testing.T) {
+
+	fn := filepath.Join(os.TempDir(), "separator", "ws.json")
+	e := os.MkdirAll(filepath.Dir(fn), 0777)
+	if e != nil {
+		t.Fatal(e)
+	}
+	e = os.WriteFile(fn, []byte("|{\"N\":1}\n| \n|\n{\"N\":2}\n"), 0644)
+	if e != nil {
+		t.Fatal(e)
+	}
+	js, err := NewJSONStreamer(fn, WithRecordSeparator('|'))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer js.Close()
+	for i := 1; i <= 2; i++ {
+		var v tt
+		err := js.Next(&v)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if v.N != i {
+			t.Fatalf("expected N=%d, got %d", i, v.N)
+		}
+	}
+	var v tt
+	if err := js.Next(&v); err != Done {
+		t.Fatalf("expected Done, got %v", err)
+	}
+}
+
+func TestJSONSeq(t *testing.T) {
+
+	fn := filepath.Join(os.TempDir(), "separator", "seq.json")
+	data := []tt{{Name: "a", N: 1}, {Name: "b", N: 2, Words: []string{"z"}}}
+	w, err := NewWriter(fn, WithJSONSeq())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, v := range data {
+		w.Write(v)
+	}
+	err = w.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := os.ReadFile(fn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "\x1e{\"Name\":\"a\",\"N\":1,\"Words\":null}\n\x1e{\"Name\":\"b\",\"N\":2,\"Words\":[\"z\"]}\n"
+	if string(b) != expected {
+		t.Fatalf("expected %q, got %q", expected, b)
+	}
+
+	// Append a record with surrounding whitespace and no trailing newline.
+	e := os.WriteFile(fn, append(b, " \x1e \t{\"N\":3} "...), 0644)
+	if e != nil {
+		t.Fatal(e)
+	}
+	data = append(data, tt{N: 3})
+	js, err := NewJSONStreamer(fn, WithJSONSeq())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer js.Close()
+	for i := 0; ; i++ {
+		var v tt
+		err := js.Next(&v)
+		if err == Done {
+			if i != len(data) {
+				t.Fatalf("expected %d objects, got %d", len(data), i)
+			}
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !v.equal(data[i]) {
+			t.Fatalf("expected %v, got %v", data[i], v)
+		}
+	}
+}
@@ -0,0 +1,175 @@
+// Copyright (c) 2015 AKUALAB INC., All rights reserved.
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ju
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// ErrClosedShardWriter is returned when writing to a ParallelShardWriter after Close.
+var ErrClosedShardWriter = errors.New("shard writer is closed")
+
+// shardQueueSize is the number of objects buffered for each worker of a ParallelShardWriter.
+const shardQueueSize = 64
+
+// ParallelShardWriter partitions json objects into files using a fixed number of goroutines.
+// The shard of an object is the file name returned by the key function, relative to the output
+// directory. The name must be a plain file name: it cannot be empty, absolute, contain a path
+// separator or "..", or change when cleaned. If the name ends in ".gz", the data is gzipped. Each shard is owned by a single worker
+// goroutine, so the objects of a shard are written in the order they are passed to Write by a
+// goroutine. Each worker has a bounded queue; Write blocks when the queue of the shard is full.
+//
+// Unlike WriterPool, which writes to any path, the files of a ParallelShardWriter are spread
+// over the workers by hashing the shard name, and a shard never moves between workers.
+type ParallelShardWriter struct {
+	dir    string
+	keyFn  func(interface{}) string
+	opts   []Option
+	queues []chan shardObject
+	wg     sync.WaitGroup
+	sendMu sync.RWMutex // guards sending on queues against Close
+	mu     sync.Mutex
+	errs   []error
+	closed bool
+}
+
+// NewParallelShardWriter creates a writer that writes the objects to the shards of directory dir
+// returned by keyFn, using the given number of worker goroutines. keyFn is called by Write and must
+// be safe for concurrent use if Write is called concurrently. The options are passed to NewWriter.
+// It is the caller's responsibility to call Close when done.
+func NewParallelShardWriter(dir string, keyFn func(interface{}) string, workers int, opts ...Option) (*ParallelShardWriter, error) {
+	o := newOptions(opts...)
+	if o.err != nil {
+		return nil, o.err
+	}
+	if workers < 1 {
+		workers = 1
+	}
+	sw := &ParallelShardWriter{
+		dir:    dir,
+		keyFn:  keyFn,
+		opts:   opts,
+		queues: make([]chan shardObject, workers),
+	}
+	sw.wg.Add(workers)
+	for i := range sw.queues {
+		q := make(chan shardObject, shardQueueSize)
+		sw.queues[i] = q
+		go sw.worker(q)
+	}
+	return sw, nil
+}
+
+// shardObject is an object with its shard name.
+type shardObject struct {
+	shard string
+	obj   interface{}
+}
+
+// Write queues object o to be written to its shard. The object is encoded asynchronously
+// so the caller must not modify it after calling Write.
+// Write returns the first error encountered so far, if any, and ErrClosedShardWriter after Close.
+// An object whose shard name is not valid is not written and Write returns an error.
+// It is safe for concurrent use.
+func (sw *ParallelShardWriter) Write(o interface{}) error {
+	sw.sendMu.RLock()
+	defer sw.sendMu.RUnlock()
+	sw.mu.Lock()
+	if sw.closed {
+		sw.mu.Unlock()
+		return ErrClosedShardWriter
+	}
+	var err error
+	if len(sw.errs) > 0 {
+		err = sw.errs[0]
+	}
+	sw.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	shard := sw.keyFn(o)
+	err = checkShard(shard)
+	if err != nil {
+		return err
+	}
+	sw.queues[workerFor(shard, len(sw.queues))] <- shardObject{shard: shard, obj: o}
+	return nil
+}
+
+// checkShard returns an error if shard is not a plain file name, so that objects cannot be
+// written outside of the output directory.
+func checkShard(shard string) error {
+	if shard == "" || shard == "." || filepath.IsAbs(shard) || filepath.Clean(shard) != shard ||
+		strings.Contains(shard, "..") || strings.ContainsAny(shard, `/\`+string(filepath.Separator)) {
+		return fmt.Errorf("invalid shard name %q", shard)
+	}
+	return nil
+}
+
+// Close waits for the workers to write all the queued objects, then closes all the files.
+// All files are closed even if errors occurred. The returned error joins all the errors.
+func (sw *ParallelShardWriter) Close() error {
+	sw.sendMu.Lock()
+	sw.mu.Lock()
+	if sw.closed {
+		sw.mu.Unlock()
+		sw.sendMu.Unlock()
+		return nil
+	}
+	sw.closed = true
+	sw.mu.Unlock()
+	for _, q := range sw.queues {
+		close(q)
+	}
+	sw.sendMu.Unlock()
+
+	sw.wg.Wait()
+	return errors.Join(sw.errs...)
+}
+
+// worker writes the objects of its queue and closes its files when the queue is closed.
+func (sw *ParallelShardWriter) worker(q chan shardObject) {
+	defer sw.wg.Done()
+	writers := map[string]*Writer{}
+	failed := map[string]bool{}
+	for so := range q {
+		if failed[so.shard] {
+			continue
+		}
+		w, ok := writers[so.shard]
+		if !ok {
+			var err error
+			w, err = NewWriter(filepath.Join(sw.dir, so.shard), sw.opts...)
+			if err != nil {
+				failed[so.shard] = true
+				sw.setErr(err)
+				continue
+			}
+			writers[so.shard] = w
+		}
+		err := w.Write(so.obj)
+		if err != nil {
+			failed[so.shard] = true
+			sw.setErr(err)
+		}
+	}
+	for _, w := range writers {
+		sw.setErr(w.Close())
+	}
+}
+
+func (sw *ParallelShardWriter) setErr(err error) {
+	if err == nil {
+		return
+	}
+	sw.mu.Lock()
+	sw.errs = append(sw.errs, err)
+	sw.mu.Unlock()
+}
@@ -0,0 +1,130 @@
+// Copyright (c) 2015 AKUALAB INC., All rights reserved.
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ju
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParallelShardWriter(t *testing.T) {
+
+	dir := filepath.Join(os.TempDir(), "shard")
+	os.RemoveAll(dir)
+	key := func(o interface{}) string {
+		x := o.(*tt)
+		if x.N%2 == 0 {
+			return fmt.Sprintf("shard-%d.json.gz", x.N%5)
+		}
+		return fmt.Sprintf("shard-%d.json", x.N%5)
+	}
+	sw, err := NewParallelShardWriter(dir, key, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 1000; i++ {
+		e := sw.Write(&tt{Name: "x", N: i})
+		if e != nil {
+			t.Fatal(e)
+		}
+	}
+	e := sw.Close()
+	if e != nil {
+		t.Fatal(e)
+	}
+	e = sw.Write(&tt{})
+	if e != ErrClosedShardWriter {
+		t.Fatalf("expected ErrClosedShardWriter, got %v", e)
+	}
+	e = sw.Close()
+	if e != nil {
+		t.Fatalf("expected a second Close to return nil, got %v", e)
+	}
+
+	files, err := ExpandPaths(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 10 {
+		t.Fatalf("expected 10 shards, got %v", files)
+	}
+	total := 0
+	for _, fn := range files {
+		js, err := NewJSONStreamer(fn)
+		if err != nil {
+			t.Fatal(err)
+		}
+		last := -1
+		for {
+			var x tt
+			err := js.Next(&x)
+			if err == Done {
+				break
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if key(&x) != filepath.Base(fn) {
+				t.Fatalf("object %d in wrong shard %s", x.N, fn)
+			}
+			if x.N <= last {
+				t.Fatalf("objects out of order in %s: %d after %d", fn, x.N, last)
+			}
+			last = x.N
+			total++
+		}
+		js.Close()
+	}
+	if total != 1000 {
+		t.Fatalf("expected 1000 objects, got %d", total)
+	}
+}
+
+func TestParallelShardWriterError(t *testing.T) {
+
+	dir := filepath.Join(os.TempDir(), "shard-err")
+	os.RemoveAll(dir)
+	sw, err := NewParallelShardWriter(dir, func(o interface{}) string { return fmt.Sprint(o, ".json") }, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sw.Write("a")
+	sw.Write(make(chan int))
+	sw.Write(func() {})
+	e := sw.Close()
+	if e == nil || !strings.Contains(e.Error(), "chan int") || !strings.Contains(e.Error(), "func()") {
+		t.Fatalf("expected combined encoding errors, got %v", e)
+	}
+}
+
+func TestParallelShardWriterInvalidShard(t *testing.T) {
+
+	dir := filepath.Join(os.TempDir(), "shard-invalid")
+	os.RemoveAll(dir)
+	sw, err := NewParallelShardWriter(dir, func(o interface{}) string { return o.(string) }, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, key := range []string{"", ".", "..", "../a.json", "a/b.json", "/tmp/a.json", "a..json", "./a.json"} {
+		if sw.Write(key) == nil {
+			t.Fatalf("expected error for shard %q", key)
+		}
+	}
+	e := sw.Write("a.json")
+	if e != nil {
+		t.Fatal(e)
+	}
+	e = sw.Close()
+	if e != nil {
+		t.Fatal(e)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "a.json")); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,244 @@
+// Copyright (c) 2015 AKUALAB INC., All rights reserved.
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ju
+
+import (
+	"container/heap"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// DefaultSortMemory is the memory budget in bytes used by Sort.
+const DefaultSortMemory = 64 << 20
+
+// DefaultSortFanIn is the maximum number of runs merged at once by Sort.
+const DefaultSortFanIn = 64
+
+// Sorter sorts json objects that may not fit in memory using an external merge sort.
+// Objects are read into sorted runs of up to MaxBytes bytes of json, each run is
+// written to a temporary file, and the runs are merged. When there are more runs than
+// FanIn, groups of consecutive runs are merged into larger runs in several passes, so that
+// no more than FanIn files are open at once.
+type Sorter struct {
+	// MaxBytes is the memory budget, the maximum size of the json in a run.
+	// Defaults to DefaultSortMemory.
+	MaxBytes int64
+	// FanIn is the maximum number of runs merged at once. Defaults to DefaultSortFanIn.
+	FanIn int
+	// TempDir is the directory for the temporary run files. Defaults to os.TempDir().
+	TempDir string
+}
+
+// Sort sorts the json objects in srcPath using less and writes them to dstPath
+// using the default Sorter. The sort is stable.
+// See FileStreamer for srcPath and ext, and NewWriter for dstPath.
+func Sort(srcPath, dstPath string, less func(a, b json.RawMessage) bool, ext ...string) error {
+	return Sorter{}.Sort(srcPath, dstPath, less, ext...)
+}
+
+// Sort sorts the json objects in srcPath using less and writes them to dstPath.
+// The sort is stable. If all the objects fit in the memory budget, no temporary files are used.
+func (s Sorter) Sort(srcPath, dstPath string, less func(a, b json.RawMessage) bool, ext ...string) error {
+
+	max := s.MaxBytes
+	if max <= 0 {
+		max = DefaultSortMemory
+	}
+	fanIn := s.FanIn
+	if fanIn < 2 {
+		fanIn = DefaultSortFanIn
+	}
+	var tmp string
+	defer func() {
+		if tmp != "" {
+			os.RemoveAll(tmp)
+		}
+	}()
+
+	var runs []string
+	var run []json.RawMessage
+	var size int64
+	flush := func() error {
+		if tmp == "" {
+			var err error
+			tmp, err = os.MkdirTemp(s.TempDir, "ju-sort-")
+			if err != nil {
+				return err
+			}
+		}
+		fn := filepath.Join(tmp, fmt.Sprintf("run-%d.json", len(runs)))
+		err := writeRun(fn, run, less)
+		if err != nil {
+			return err
+		}
+		runs = append(runs, fn)
+		run = nil
+		size = 0
+		return nil
+	}
+	err := forEachRaw(srcPath, ext, func(raw json.RawMessage) error {
+		run = append(run, raw)
+		size += int64(len(raw))
+		if size >= max {
+			return flush()
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if len(runs) == 0 {
+		return writeRun(dstPath, run, less)
+	}
+	if len(run) > 0 {
+		err = flush()
+		if err != nil {
+			return err
+		}
+	}
+	// Merging consecutive runs keeps the sort stable.
+	for pass := 0; len(runs) > fanIn; pass++ {
+		var merged []string
+		for i := 0; i < len(runs); i += fanIn {
+			end := i + fanIn
+			if end > len(runs) {
+				end = len(runs)
+			}
+			group := runs[i:end]
+			fn := filepath.Join(tmp, fmt.Sprintf("merge-%d-%d.json", pass, len(merged)))
+			err = mergeRuns(group, fn, less)
+			if err != nil {
+				return err
+			}
+			for _, r := range group {
+				os.Remove(r)
+			}
+			merged = append(merged, fn)
+		}
+		runs = merged
+	}
+	return mergeRuns(runs, dstPath, less)
+}
+
+// writeRun sorts the objects and writes them to fn.
+func writeRun(fn string, run []json.RawMessage, less func(a, b json.RawMessage) bool) error {
+	sort.SliceStable(run, func(i, j int) bool { return less(run[i], run[j]) })
+	w, err := NewWriter(fn)
+	if err != nil {
+		return err
+	}
+	for _, raw := range run {
+		err = w.Write(raw)
+		if err != nil {
+			w.Close()
+			return err
+		}
+	}
+	return w.Close()
+}
+
+// mergeRuns merges the sorted runs into dstPath.
+func mergeRuns(runs []string, dstPath string, less func(a, b json.RawMessage) bool) error {
+
+	h := &runHeap{less: less}
+	defer func() {
+		for _, r := range h.runs {
+			r.js.Close()
+		}
+	}()
+	for i, fn := range runs {
+		js, err := NewJSONStreamer(fn)
+		if err != nil {
+			return err
+		}
+		r := &runReader{js: js, idx: i}
+		ok, err := r.next()
+		if err != nil {
+			js.Close()
+			return err
+		}
+		if ok {
+			heap.Push(h, r)
+		} else {
+			js.Close()
+		}
+	}
+
+	w, err := NewWriter(dstPath)
+	if err != nil {
+		return err
+	}
+	for h.Len() > 0 {
+		r := h.runs[0]
+		err = w.Write(r.raw)
+		if err != nil {
+			w.Close()
+			return err
+		}
+		ok, err := r.next()
+		if err != nil {
+			w.Close()
+			return err
+		}
+		if ok {
+			heap.Fix(h, 0)
+		} else {
+			heap.Pop(h)
+			r.js.Close()
+		}
+	}
+	return w.Close()
+}
+
+type runReader struct {
+	js  *JSONStreamer
+	idx int
+	raw json.RawMessage
+}
+
+func (r *runReader) next() (bool, error) {
+	var raw json.RawMessage
+	err := r.js.Next(&raw)
+	if err == Done {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	r.raw = raw
+	return true, nil
+}
+
+// runHeap orders the runs by their next object. Ties are broken by run index to keep the sort stable.
+type runHeap struct {
+	runs []*runReader
+	less func(a, b json.RawMessage) bool
+}
+
+func (h *runHeap) Len() int { return len(h.runs) }
+
+func (h *runHeap) Less(i, j int) bool {
+	a, b := h.runs[i], h.runs[j]
+	if h.less(a.raw, b.raw) {
+		return true
+	}
+	if h.less(b.raw, a.raw) {
+		return false
+	}
+	return a.idx < b.idx
+}
+
+func (h *runHeap) Swap(i, j int)      { h.runs[i], h.runs[j] = h.runs[j], h.runs[i] }
+func (h *runHeap) Push(x interface{}) { h.runs = append(h.runs, x.(*runReader)) }
+
+func (h *runHeap) Pop() interface{} {
+	r := h.runs[len(h.runs)-1]
+	h.runs = h.runs[:len(h.runs)-1]
+	return r
+}
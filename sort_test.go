@@ -0,0 +1,68 @@
+// Copyright (c) 2015 AKUALAB INC., All rights reserved.
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ju
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSort(t *testing.T) {
+
+	dir := filepath.Join(os.TempDir(), "sort")
+	os.RemoveAll(dir)
+	src := filepath.Join(dir, "src.json.gz")
+	var objs []tt
+	for i := 0; i < 1000; i++ {
+		// N has many duplicates, Name records the input order.
+		objs = append(objs, tt{Name: "sort", N: (i * 7919) % 97, Words: []string{string(rune('a' + i%26))}})
+	}
+	for i := range objs {
+		objs[i].Words = append(objs[i].Words, string(rune('0'+i/100)))
+	}
+	e := WriteAll(src, objs)
+	if e != nil {
+		t.Fatal(e)
+	}
+	less := func(a, b json.RawMessage) bool {
+		var x, y tt
+		json.Unmarshal(a, &x)
+		json.Unmarshal(b, &y)
+		return x.N < y.N
+	}
+
+	for _, s := range []Sorter{{}, {MaxBytes: 2000, TempDir: dir}, {MaxBytes: 500, FanIn: 3, TempDir: dir}} {
+		dst := filepath.Join(dir, "out", "sorted.json")
+		e := s.Sort(src, dst, less)
+		if e != nil {
+			t.Fatal(e)
+		}
+		sorted, err := CollectParallel[tt](dst, 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(sorted) != len(objs) {
+			t.Fatalf("expected %d objects, got %d", len(objs), len(sorted))
+		}
+		for i := 1; i < len(sorted); i++ {
+			a, b := sorted[i-1], sorted[i]
+			if a.N > b.N {
+				t.Fatalf("not sorted at %d: %d > %d", i, a.N, b.N)
+			}
+			// Stable: objects with the same key keep the input order.
+			if a.N == b.N && a.Words[1] > b.Words[1] {
+				t.Fatalf("not stable at %d: %v, %v", i, a, b)
+			}
+		}
+	}
+	// Temporary files are removed.
+	matches, _ := filepath.Glob(filepath.Join(dir, "ju-sort-*"))
+	if len(matches) != 0 {
+		t.Fatalf("temporary files not removed: %v", matches)
+	}
+}
@@ -0,0 +1,38 @@
+// Copyright (c) 2015 AKUALAB INC., All rights reserved.
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ju
+
+import (
+	"bytes"
+	"encoding/json"
+)
+
+// WithSortedKeys makes a Writer emit the members of all objects, at any depth, in sorted key
+// order, so that the output is reproducible byte for byte. encoding/json sorts the keys of maps
+// but writes struct fields in declaration order. Each object is encoded, decoded, and encoded
+// again, which slows down writing. Numbers are written as they were encoded.
+func WithSortedKeys() Option {
+	return func(o *options) {
+		o.sortKeys = true
+	}
+}
+
+// sortKeys returns v encoded with the keys of all objects sorted.
+func sortKeys(v interface{}) (json.RawMessage, error) {
+	data, err := DefaultCodec.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var x interface{}
+	err = dec.Decode(&x)
+	if err != nil {
+		return nil, err
+	}
+	// Maps are encoded in sorted key order.
+	return json.Marshal(x)
+}
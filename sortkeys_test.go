@@ -0,0 +1,50 @@
+// Copyright (c) 2015 AKUALAB INC., All rights reserved.
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ju
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSortedKeys(t *testing.T) {
+
+	type inner struct {
+		Z int
+		A string
+	}
+	type outer struct {
+		Name  string
+		Big   int64
+		Inner inner
+		List  []inner
+		Attrs map[string]int
+	}
+
+	fn := filepath.Join(os.TempDir(), "sortkeys", "s.json")
+	w, err := NewWriter(fn, WithSortedKeys(), WithAutoID("ID", 1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	e := w.Write(outer{Name: "a", Big: 1 << 60, Inner: inner{Z: 1, A: "x"}, List: []inner{{Z: 2}}, Attrs: map[string]int{"b": 1, "a": 2}})
+	if e != nil {
+		t.Fatal(e)
+	}
+	e = w.Close()
+	if e != nil {
+		t.Fatal(e)
+	}
+
+	data, err := os.ReadFile(fn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `{"Attrs":{"a":2,"b":1},"Big":1152921504606846976,"ID":1,"Inner":{"A":"x","Z":1},"List":[{"A":"","Z":2}],"Name":"a"}` + "\n"
+	if string(data) != expected {
+		t.Fatalf("expected %s, got %s", expected, data)
+	}
+}
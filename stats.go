@@ -0,0 +1,39 @@
+// Copyright (c) 2015 AKUALAB INC., All rights reserved.
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ju
+
+import (
+	"time"
+)
+
+// FileStat has statistics about a file read by a streamer.
+type FileStat struct {
+	// Path of the file, or the name of the entry in an archive.
+	Path string
+	// Objects is the number of json objects decoded from the file.
+	Objects int64
+	// Bytes is the number of bytes read from the file, after decompression.
+	Bytes int64
+	// Duration is the time spent from opening to closing the file.
+	Duration time.Duration
+}
+
+// WithStats collects per-file statistics. See JSONStreamer.Stats.
+func WithStats() Option {
+	return func(o *options) {
+		o.stats = true
+	}
+}
+
+// Stats returns statistics for the files read so far, in the order they were read.
+// The last entry may be for a file that is still being read.
+// Statistics are only collected when the streamer is created with WithStats.
+func (js *JSONStreamer) Stats() []FileStat {
+	if js.m == nil {
+		return nil
+	}
+	return js.m.stats
+}
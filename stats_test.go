@@ -0,0 +1,68 @@
+// Copyright (c) 2015 AKUALAB INC., All rights reserved.
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ju
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestStats(t *testing.T) {
+
+	dir := filepath.Join(os.TempDir(), "stats")
+	os.RemoveAll(dir)
+	var sizes []int64
+	for k := 0; k < 3; k++ {
+		fn := filepath.Join(dir, fmt.Sprintf("testfile-%d.json", k))
+		objs := make([]tt, k+1)
+		e := WriteAll(fn, objs)
+		if e != nil {
+			t.Fatal(e)
+		}
+		fi, err := os.Stat(fn)
+		if err != nil {
+			t.Fatal(err)
+		}
+		sizes = append(sizes, fi.Size())
+	}
+	// Empty file.
+	e := os.WriteFile(filepath.Join(dir, "testfile-3.json"), nil, 0644)
+	if e != nil {
+		t.Fatal(e)
+	}
+	sizes = append(sizes, 0)
+
+	js, err := NewJSONStreamer(dir, WithStats())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for {
+		var o tt
+		e := js.Next(&o)
+		if e == Done {
+			break
+		}
+		if e != nil {
+			t.Fatal(e)
+		}
+	}
+	stats := js.Stats()
+	js.Close()
+	if len(stats) != 4 {
+		t.Fatalf("expected 4 stats, got %d", len(stats))
+	}
+	for k, st := range stats {
+		t.Log(st)
+		if filepath.Base(st.Path) != fmt.Sprintf("testfile-%d.json", k) {
+			t.Fatalf("unexpected path %s", st.Path)
+		}
+		if st.Objects != int64(k+1)%4 || st.Bytes != sizes[k] {
+			t.Fatalf("unexpected stat %v", st)
+		}
+	}
+}
@@ -0,0 +1,56 @@
+// Copyright (c) 2015 AKUALAB INC., All rights reserved.
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ju
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+)
+
+func TestSyncWriter(t *testing.T) {
+
+	fn := filepath.Join(os.TempDir(), "syncwriter", "s.json.gz")
+	w, err := NewSyncWriter(fn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < 100; i++ {
+				e := w.Write(&tt{Name: "sync", N: g*100 + i, Words: []string{"a", "b"}})
+				if e != nil {
+					t.Error(e)
+					return
+				}
+				if i%10 == 0 {
+					w.Flush()
+				}
+			}
+		}(g)
+	}
+	wg.Wait()
+	e := w.Close()
+	if e != nil {
+		t.Fatal(e)
+	}
+
+	objs, err := CollectParallel[tt](fn, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	seen := map[int]bool{}
+	for _, o := range objs {
+		seen[o.N] = true
+	}
+	if len(objs) != 800 || len(seen) != 800 {
+		t.Fatalf("expected 800 distinct objects, got %d (%d distinct)", len(objs), len(seen))
+	}
+}
@@ -0,0 +1,69 @@
+// Copyright (c) 2015 AKUALAB INC., All rights reserved.
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ju
+
+import (
+	"bytes"
+	"fmt"
+	"sync/atomic"
+	"time"
+)
+
+// DefaultTimeLayout is the initial layout used by Time.
+const DefaultTimeLayout = "2006-01-02 15:04:05"
+
+var timeLayout atomic.Value
+
+func init() {
+	timeLayout.Store(DefaultTimeLayout)
+}
+
+// SetTimeLayout sets the layout used by Time to marshal and unmarshal json strings.
+// See time.Parse for the layout format. It is safe to call concurrently but should be
+// called before decoding starts.
+func SetTimeLayout(layout string) {
+	timeLayout.Store(layout)
+}
+
+// TimeLayout returns the layout used by Time.
+func TimeLayout() string {
+	return timeLayout.Load().(string)
+}
+
+// Time is a time.Time that is encoded as a json string using the layout set with SetTimeLayout.
+// Use it in struct fields to decode timestamps that are not in RFC 3339 format.
+// A json null decodes as the zero time and the zero time encodes as null.
+type Time struct {
+	time.Time
+}
+
+// MarshalJSON implements the json.Marshaler interface.
+func (t Time) MarshalJSON() ([]byte, error) {
+	if t.IsZero() {
+		return []byte("null"), nil
+	}
+	return DefaultCodec.Marshal(t.Format(TimeLayout()))
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface.
+func (t *Time) UnmarshalJSON(data []byte) error {
+	if bytes.Equal(data, []byte("null")) {
+		t.Time = time.Time{}
+		return nil
+	}
+	var s string
+	err := DefaultCodec.Unmarshal(data, &s)
+	if err != nil {
+		return fmt.Errorf("ju.Time: %w", err)
+	}
+	layout := TimeLayout()
+	tm, err := time.Parse(layout, s)
+	if err != nil {
+		return fmt.Errorf("ju.Time: cannot parse %q with layout %q: %w", s, layout, err)
+	}
+	t.Time = tm
+	return nil
+}
@@ -0,0 +1,53 @@
+// Copyright (c) 2015 AKUALAB INC., All rights reserved.
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ju
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+type event struct {
+	Name string
+	At   Time
+	Done Time
+}
+
+func TestTime(t *testing.T) {
+
+	var ev event
+	e := json.Unmarshal([]byte(`{"Name":"a","At":"2015-03-04 05:06:07","Done":null}`), &ev)
+	if e != nil {
+		t.Fatal(e)
+	}
+	expected := time.Date(2015, 3, 4, 5, 6, 7, 0, time.UTC)
+	if !ev.At.Equal(expected) || !ev.Done.IsZero() {
+		t.Fatalf("unexpected times: %v", ev)
+	}
+	data, e := json.Marshal(ev)
+	if e != nil {
+		t.Fatal(e)
+	}
+	if string(data) != `{"Name":"a","At":"2015-03-04 05:06:07","Done":null}` {
+		t.Fatalf("unexpected json: %s", data)
+	}
+
+	e = json.Unmarshal([]byte(`{"At":"2015-03-04T05:06:07Z"}`), &ev)
+	if e == nil {
+		t.Fatal("expected parse error")
+	}
+
+	SetTimeLayout("02/01/2006")
+	defer SetTimeLayout(DefaultTimeLayout)
+	e = json.Unmarshal([]byte(`{"At":"04/03/2015"}`), &ev)
+	if e != nil {
+		t.Fatal(e)
+	}
+	if !ev.At.Equal(time.Date(2015, 3, 4, 0, 0, 0, 0, time.UTC)) {
+		t.Fatalf("unexpected time: %v", ev.At)
+	}
+}
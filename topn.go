@@ -0,0 +1,94 @@
+// Copyright (c) 2015 AKUALAB INC., All rights reserved.
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ju
+
+import (
+	"container/heap"
+	"encoding/json"
+	"errors"
+	"math"
+	"sort"
+)
+
+// ErrNoScore can be returned by the score function of TopN to skip an object without a score.
+var ErrNoScore = errors.New("no score")
+
+// TopN returns the n json objects from srcPath with the highest scores, in descending order of score,
+// reading the files once. Scores are computed with scoreFn. Only n objects are kept in memory.
+// Ties are broken by read order: among objects with the same score, the ones read first are kept
+// and returned first. Objects for which scoreFn returns an error wrapping ErrNoScore, or a NaN score,
+// are skipped; any other error stops the stream and is returned.
+// See FileStreamer for srcPath and ext.
+func TopN(srcPath string, n int, scoreFn func(json.RawMessage) (float64, error), ext ...string) ([]json.RawMessage, error) {
+
+	if n <= 0 {
+		return nil, nil
+	}
+	h := &scoreHeap{}
+	var seq int64
+	err := forEachRaw(srcPath, ext, func(raw json.RawMessage) error {
+		score, err := scoreFn(raw)
+		if errors.Is(err, ErrNoScore) || (err == nil && math.IsNaN(score)) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		seq++
+		if h.Len() < n {
+			heap.Push(h, scored{raw: raw, score: score, seq: seq})
+			return nil
+		}
+		// Replace the lowest score; a tie does not replace an object read earlier.
+		if score > h.items[0].score {
+			h.items[0] = scored{raw: raw, score: score, seq: seq}
+			heap.Fix(h, 0)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(h.items, func(i, j int) bool { return h.less(h.items[j], h.items[i]) })
+	top := make([]json.RawMessage, len(h.items))
+	for i, s := range h.items {
+		top[i] = s.raw
+	}
+	return top, nil
+}
+
+// scored is an object with its score and read order.
+type scored struct {
+	raw   json.RawMessage
+	score float64
+	seq   int64
+}
+
+// scoreHeap is a min-heap of objects by score. Among equal scores, the object read last is the smallest.
+type scoreHeap struct {
+	items []scored
+}
+
+func (h *scoreHeap) less(a, b scored) bool {
+	if a.score != b.score {
+		return a.score < b.score
+	}
+	return a.seq > b.seq
+}
+
+func (h *scoreHeap) Len() int           { return len(h.items) }
+func (h *scoreHeap) Less(i, j int) bool { return h.less(h.items[i], h.items[j]) }
+func (h *scoreHeap) Swap(i, j int)      { h.items[i], h.items[j] = h.items[j], h.items[i] }
+
+func (h *scoreHeap) Push(x interface{}) {
+	h.items = append(h.items, x.(scored))
+}
+
+func (h *scoreHeap) Pop() interface{} {
+	s := h.items[len(h.items)-1]
+	h.items = h.items[:len(h.items)-1]
+	return s
+}
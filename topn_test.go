@@ -0,0 +1,67 @@
+// Copyright (c) 2015 AKUALAB INC., All rights reserved.
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ju
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestTopN(t *testing.T) {
+
+	fn := filepath.Join(os.TempDir(), "topn", "data.json.gz")
+	data := []tt{
+		{Name: "a", N: 5}, {Name: "b", N: 9}, {Name: "c", N: -1}, {Name: "d", N: 7},
+		{Name: "e", N: 9}, {Name: "f", N: 1}, {Name: "g", N: 7}, {Name: "h", N: 8},
+	}
+	e := WriteAll(fn, data)
+	if e != nil {
+		t.Fatal(e)
+	}
+	score := func(raw json.RawMessage) (float64, error) {
+		var x tt
+		err := json.Unmarshal(raw, &x)
+		if err != nil {
+			return 0, err
+		}
+		if x.N < 0 {
+			return 0, ErrNoScore
+		}
+		return float64(x.N), nil
+	}
+	names := func(objs []json.RawMessage) string {
+		var s string
+		for _, raw := range objs {
+			var x tt
+			e := json.Unmarshal(raw, &x)
+			if e != nil {
+				t.Fatal(e)
+			}
+			s += x.Name
+		}
+		return s
+	}
+
+	// Ties are kept and returned in read order.
+	for n, expected := range map[int]string{1: "b", 2: "be", 4: "behd", 5: "behdg", 10: "behdgaf"} {
+		top, err := TopN(fn, n, score)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := names(top); got != expected {
+			t.Fatalf("top %d: expected %s, got %s", n, expected, got)
+		}
+	}
+
+	errBad := errors.New("bad")
+	_, err := TopN(fn, 4, func(json.RawMessage) (float64, error) { return 0, errBad })
+	if !errors.Is(err, errBad) {
+		t.Fatalf("expected the score error, got %v", err)
+	}
+}
@@ -0,0 +1,54 @@
+// Copyright (c) 2015 AKUALAB INC., All rights reserved.
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ju
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"unicode/utf8"
+)
+
+// UTF8Mode selects what WithUTF8Validation does with invalid UTF-8.
+type UTF8Mode int
+
+const (
+	// UTF8Reject fails on objects that contain invalid UTF-8.
+	UTF8Reject UTF8Mode = iota
+	// UTF8Replace replaces each invalid sequence with the Unicode replacement character U+FFFD.
+	UTF8Replace
+)
+
+// WithUTF8Validation makes a JSONStreamer check that each object is valid UTF-8. With UTF8Reject,
+// the error wraps ErrInvalid and gives the file and the offset of the first invalid byte in the
+// object, so WithSkipInvalid skips these objects. With UTF8Replace, invalid sequences are replaced
+// before the object is unmarshaled, which also applies to json.RawMessage destinations.
+// Note that encoding/json already replaces invalid UTF-8 when unmarshaling into strings.
+func WithUTF8Validation(mode UTF8Mode) Option {
+	return func(o *options) {
+		o.filters = append(o.filters, func(raw json.RawMessage) (json.RawMessage, error) {
+			if utf8.Valid(raw) {
+				return raw, nil
+			}
+			if mode == UTF8Replace {
+				return bytes.ToValidUTF8(raw, []byte(string(utf8.RuneError))), nil
+			}
+			return raw, fmt.Errorf("%w: invalid UTF-8 at byte %d of object", ErrInvalid, invalidUTF8(raw))
+		})
+	}
+}
+
+// invalidUTF8 returns the offset of the first invalid UTF-8 sequence in data, or -1.
+func invalidUTF8(data []byte) int {
+	for i := 0; i < len(data); {
+		r, n := utf8.DecodeRune(data[i:])
+		if r == utf8.RuneError && n == 1 {
+			return i
+		}
+		i += n
+	}
+	return -1
+}
@@ -0,0 +1,89 @@
+// Copyright (c) 2015 AKUALAB INC., All rights reserved.
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ju
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestUTF8Validation(t *testing.T) {
+
+	fn := filepath.Join(os.TempDir(), "utf8", "u.json")
+	e := os.MkdirAll(filepath.Dir(fn), 0777)
+	if e != nil {
+		t.Fatal(e)
+	}
+	// Invalid start byte, truncated sequence, and encoded surrogate.
+	data := "{\"Name\":\"ok\"}\n{\"Name\":\"a\xffb\"}\n{\"Name\":\"\xe2\x82\"}\n{\"Name\":\"\xed\xa0\x80\"}\n{\"Name\":\"é\"}\n"
+	e = os.WriteFile(fn, []byte(data), 0644)
+	if e != nil {
+		t.Fatal(e)
+	}
+
+	js, err := NewJSONStreamer(fn, WithUTF8Validation(UTF8Reject))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer js.Close()
+	var raw json.RawMessage
+	e = js.Next(&raw)
+	if e != nil {
+		t.Fatal(e)
+	}
+	e = js.Next(&raw)
+	var fe *FileError
+	if !errors.Is(e, ErrInvalid) || !errors.As(e, &fe) || fe.Path != fn || !strings.Contains(e.Error(), "byte 10") {
+		t.Fatalf("expected ErrInvalid at byte 10 of %s, got %v", fn, e)
+	}
+
+	js, err = NewJSONStreamer(fn, WithUTF8Validation(UTF8Reject), WithSkipInvalid())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer js.Close()
+	var names []string
+	for {
+		var x tt
+		e := js.Next(&x)
+		if e == Done {
+			break
+		}
+		if e != nil {
+			t.Fatal(e)
+		}
+		names = append(names, x.Name)
+	}
+	if strings.Join(names, ",") != "ok,é" {
+		t.Fatalf("expected the valid objects, got %q", names)
+	}
+
+	js, err = NewJSONStreamer(fn, WithUTF8Validation(UTF8Replace))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer js.Close()
+	var got []string
+	for {
+		var raw json.RawMessage
+		e := js.Next(&raw)
+		if e == Done {
+			break
+		}
+		if e != nil {
+			t.Fatal(e)
+		}
+		got = append(got, string(raw))
+	}
+	expected := []string{`{"Name":"ok"}`, `{"Name":"a` + "�" + `b"}`, `{"Name":"` + "�" + `"}`, `{"Name":"` + "�" + `"}`, `{"Name":"é"}`}
+	if strings.Join(got, "\n") != strings.Join(expected, "\n") {
+		t.Fatalf("expected %q, got %q", expected, got)
+	}
+}
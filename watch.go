@@ -0,0 +1,99 @@
+// Copyright (c) 2015 AKUALAB INC., All rights reserved.
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ju
+
+import (
+	"context"
+	"os"
+	"time"
+)
+
+// WatchDir watches a directory and sends the path of each new file to the returned channel.
+// Files present when WatchDir is called are ignored. Files are selected using the same rules
+// as FileStreamer for directories. To avoid emitting files that are still being written, a
+// file is sent only after its size and modification time did not change between two polls.
+// The directory is polled every 250ms; use WithPollInterval to change it. See FileStreamer for ext.
+//
+// Polling is intentional: it only uses the standard library and works the same on every
+// platform and on network filesystems, where notification APIs such as inotify may not
+// report changes.
+//
+// Both channels are closed when ctx is cancelled or an error occurs. Errors are sent to the
+// error channel before closing it.
+func WatchDir(ctx context.Context, dir string, ext []string, opts ...Option) (<-chan string, <-chan error) {
+
+	o := newOptions(opts...)
+	out := make(chan string)
+	errCh := make(chan error, 1)
+	go func() {
+		defer close(errCh)
+		defer close(out)
+		err := watch(ctx, dir, out, ext, o)
+		if err != nil && err != ctx.Err() {
+			errCh <- err
+		}
+	}()
+	return out, errCh
+}
+
+type fileState struct {
+	size    int64
+	modTime time.Time
+}
+
+func watch(ctx context.Context, dir string, out chan<- string, ext []string, o *options) error {
+
+	seen := map[string]bool{}
+	paths, err := extractPaths(dir, o, ext...)
+	if err != nil {
+		return err
+	}
+	for _, p := range paths {
+		seen[p] = true
+	}
+
+	pending := map[string]fileState{}
+	ticker := time.NewTicker(o.pollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		paths, err := extractPaths(dir, o, ext...)
+		if err != nil {
+			return err
+		}
+		for _, p := range paths {
+			if seen[p] {
+				continue
+			}
+			fi, err := os.Stat(p)
+			if os.IsNotExist(err) {
+				delete(pending, p)
+				continue
+			}
+			if err != nil {
+				return err
+			}
+			st := fileState{size: fi.Size(), modTime: fi.ModTime()}
+			prev, ok := pending[p]
+			if !ok || prev != st {
+				// New or still changing.
+				pending[p] = st
+				continue
+			}
+			delete(pending, p)
+			seen[p] = true
+			select {
+			case out <- p:
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+	}
+}
@@ -0,0 +1,60 @@
+// Copyright (c) 2015 AKUALAB INC., All rights reserved.
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ju
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestWatchDir(t *testing.T) {
+
+	dir := filepath.Join(os.TempDir(), "watch")
+	os.RemoveAll(dir)
+	e := os.MkdirAll(dir, 0777)
+	if e != nil {
+		t.Fatal(e)
+	}
+	old := filepath.Join(dir, "old.json")
+	e = os.WriteFile(old, []byte("{}\n"), 0644)
+	if e != nil {
+		t.Fatal(e)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	paths, errs := WatchDir(ctx, dir, []string{".json"}, WithPollInterval(20*time.Millisecond))
+
+	time.Sleep(50 * time.Millisecond)
+	e = os.WriteFile(filepath.Join(dir, "skip.txt"), []byte("x"), 0644)
+	if e != nil {
+		t.Fatal(e)
+	}
+	fn := filepath.Join(dir, "new.json.gz")
+	e = WriteAll(fn, []tt{{N: 1}})
+	if e != nil {
+		t.Fatal(e)
+	}
+
+	select {
+	case p := <-paths:
+		if p != fn {
+			t.Fatalf("expected %s, got %s", fn, p)
+		}
+	case e := <-errs:
+		t.Fatal(e)
+	}
+	cancel()
+	for p := range paths {
+		t.Fatalf("unexpected path %s", p)
+	}
+	if e := <-errs; e != nil {
+		t.Fatal(e)
+	}
+}